@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// WebP 扩展格式标志与 ANMF 帧标志
+const (
+	webpFlagAnimation = 0x02
+	webpFlagAlpha     = 0x10
+	anmfNoBlend       = 0x02
+)
+
+// 将所有帧写为动画 WebP 文件，编码失败时删除写了一半的文件
+func saveAnimatedWebP(path string, frames []image.Image, delays []int, loopCount int, opts *options) error {
+	outFile, err := createOutput(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer doneOutput(path)
+	if err := encodeAnimatedWebP(outFile, frames, delays, loopCount, opts); err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return fmt.Errorf("encoding: %w", err)
+	}
+	return outFile.Close()
+}
+
+// 编码动画 WebP：WebP 库只能编码静态图像，因此先逐帧编码为静态 WebP，
+// 再取出其中的图像数据块封装为 ANMF 帧。delays 单位为 1/100 秒，
+// 播放次数按 gifconv.PlayCount 由 loopCount 换算
+func encodeAnimatedWebP(w io.Writer, frames []image.Image, delays []int, loopCount int, opts *options) error {
+	if len(frames) == 0 {
+		return gifconv.ErrNoFrames
+	}
+	canvas := frames[0].Bounds().Size()
+
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation | webpFlagAlpha
+	putUint24(vp8x[4:], canvas.X-1)
+	putUint24(vp8x[7:], canvas.Y-1)
+	writeRIFFChunk(&body, "VP8X", vp8x)
+
+	// 背景色为透明，播放次数 0 表示无限循环
+	anim := make([]byte, 6)
+	binary.LittleEndian.PutUint16(anim[4:], uint16(min(gifconv.PlayCount(loopCount), 0xffff)))
+	writeRIFFChunk(&body, "ANIM", anim)
+
+	for n, frame := range frames {
+		size := frame.Bounds().Size()
+		if size != canvas {
+			return fmt.Errorf("frame %d is %dx%d, want %dx%d", n, size.X, size.Y, canvas.X, canvas.Y)
+		}
+		var still bytes.Buffer
+		if err := opts.converter.Encode(&still, frame); err != nil {
+			return fmt.Errorf("encoding frame %d: %w", n, err)
+		}
+		data, err := webpImageChunks(still.Bytes())
+		if err != nil {
+			return fmt.Errorf("encoding frame %d: %w", n, err)
+		}
+
+		// 每帧都是完整画布，不与上一帧混合
+		anmf := make([]byte, 16, 16+len(data))
+		putUint24(anmf[6:], size.X-1)
+		putUint24(anmf[9:], size.Y-1)
+		putUint24(anmf[12:], min(delays[n]*10, 0xffffff))
+		anmf[15] = anmfNoBlend
+		writeRIFFChunk(&body, "ANMF", append(anmf, data...))
+	}
+
+	var header [8]byte
+	copy(header[:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(body.Len()))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(body.Bytes())
+	return err
+}
+
+// 从静态 WebP 文件中取出 ALPH、VP8 与 VP8L 数据块（含块头）
+func webpImageChunks(file []byte) ([]byte, error) {
+	if len(file) < 12 || string(file[0:4]) != "RIFF" || string(file[8:12]) != "WEBP" {
+		return nil, errors.New("not a WebP file")
+	}
+	var data []byte
+	for rest := file[12:]; len(rest) >= 8; {
+		size := int(binary.LittleEndian.Uint32(rest[4:8]))
+		end := 8 + size + size&1
+		if end > len(rest) {
+			return nil, errors.New("truncated WebP chunk")
+		}
+		switch string(rest[0:4]) {
+		case "ALPH", "VP8 ", "VP8L":
+			data = append(data, rest[:end]...)
+		}
+		rest = rest[end:]
+	}
+	if data == nil {
+		return nil, errors.New("no image data in WebP file")
+	}
+	return data, nil
+}
+
+// 写出一个 RIFF 数据块，奇数长度时补齐一个字节
+func writeRIFFChunk(w *bytes.Buffer, fourCC string, data []byte) {
+	var header [8]byte
+	copy(header[:], fourCC)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	w.Write(header[:])
+	w.Write(data)
+	if len(data)%2 == 1 {
+		w.WriteByte(0)
+	}
+}
+
+// 以小端序写入 24 位整数
+func putUint24(b []byte, v int) {
+	b[0] = byte(v)
+	b[1] = byte(v >> 8)
+	b[2] = byte(v >> 16)
+}
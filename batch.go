@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// 单个输入文件的转换结果
+type fileResult struct {
+	input   string
+	err     error
+	elapsed time.Duration
+}
+
+// 用 opts.fileWorkers 个协程并行转换多个输入文件，单个文件内的帧仍按顺序处理。
+// 单个文件失败不影响其余文件，-fail-fast 时不再开始其余文件，结果按输入顺序返回
+func convertAll(ctx context.Context, conversions []conversion, opts *options) []fileResult {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	results := make([]fileResult, len(conversions))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.fileWorkers, len(conversions)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				c := conversions[n]
+				results[n].input = c.input
+				if err := ctx.Err(); err != nil {
+					results[n].err = err
+					continue
+				}
+				if len(conversions) > 1 {
+					opts.infof("Converting %s", c.input)
+				}
+				started := time.Now()
+				err := convertFile(ctx, c.input, c.outputDir, opts)
+				results[n].elapsed = time.Since(started)
+				if err != nil {
+					log.Printf("Error converting %s: %v", c.input, err)
+					results[n].err = err
+					if opts.failFast {
+						stop()
+					}
+				}
+			}
+		}()
+	}
+	for n := range conversions {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// 打印每个输入文件的转换结果表
+func printSummary(results []fileResult) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSTATUS\tTIME")
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = "error: " + r.err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.input, status, r.elapsed.Round(time.Millisecond))
+	}
+	tw.Flush()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// -combine 的排列方式
+const (
+	combineHorizontal = "horizontal"
+	combineGrid       = "grid"
+)
+
+// 合并模式中的一个输入：done 表示动画已播放完，之后保持最后一帧
+type combineSource struct {
+	comp  *gifconv.Compositor
+	delay []int
+	done  bool
+}
+
+// 合并模式：将多个 GIF 按相同的帧序号排列到同一画布上逐帧输出，
+// horizontal 时从左到右排成一行，grid 时排成接近正方形的网格。
+// 单元格大小取各输入画布的最大尺寸，较短的动画在结束后保持最后一帧
+func combineGIFs(ctx context.Context, inputs []string, outputDir string, opts *options) error {
+	sources := make([]*combineSource, len(inputs))
+	var cell image.Point
+	total := 0
+	for n, input := range inputs {
+		g, decoder, _, err := decodeInput(ctx, input, opts.lowMemory, false)
+		if err != nil {
+			return fmt.Errorf("%s: %w", input, err)
+		}
+		if opts.maxFrames > 0 && len(g.Image) > opts.maxFrames {
+			if !opts.truncateFrames {
+				return fmt.Errorf("%s: GIF has %d frames, more than -max-frames %d", input, len(g.Image), opts.maxFrames)
+			}
+			log.Printf("Warning: %s has %d frames, converting only the first %d", input, len(g.Image), opts.maxFrames)
+			truncateFrames(g, opts.maxFrames)
+		}
+		size := gifconv.CanvasBounds(g).Size()
+		sources[n] = &combineSource{comp: newCompositor(g, decoder), delay: g.Delay}
+		cell.X = max(cell.X, size.X)
+		cell.Y = max(cell.Y, size.Y)
+		total = max(total, len(g.Image))
+	}
+
+	frames, err := newFrameRange(opts.start, opts.end, opts.step, opts.every, total)
+	if err != nil {
+		return fmt.Errorf("invalid frame range: %w", err)
+	}
+	frames.offset = opts.indexOffset
+	opts = opts.forCombined(total)
+
+	columns := len(sources)
+	if opts.combineLayout == combineGrid {
+		columns = int(math.Ceil(math.Sqrt(float64(len(sources)))))
+	}
+	rows := (len(sources) + columns - 1) / columns
+
+	// 裁剪区域作用于合并后的画布，在写出任何帧之前校验并限制到其范围内
+	if !opts.crop.Empty() {
+		crop, err := gifconv.ClampCrop(opts.crop, image.Rect(0, 0, columns*cell.X, rows*cell.Y))
+		if err != nil {
+			return err
+		}
+		combinedOpts := *opts
+		combinedOpts.crop = crop
+		opts = &combinedOpts
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	baseFileName := opts.baseName
+	if baseFileName == "" {
+		baseFileName = "combined"
+	}
+	outputIndices := frames.outputIndices(opts.reverse)
+
+	dedupe := newDeduper(opts)
+	saved := 0
+	for i := 0; i <= frames.end; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 所有输入同步前进一帧，已结束的输入保持其画布不变；
+		// 文件名中的 {delay} 取第一个仍在播放的输入的延迟
+		delay := -1
+		for _, src := range sources {
+			if src.done {
+				continue
+			}
+			j, err := src.comp.Advance()
+			if err == io.EOF {
+				src.done = true
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if delay < 0 && j < len(src.delay) {
+				delay = src.delay[j]
+			}
+		}
+		if !frames.contains(i) {
+			continue
+		}
+
+		combined := image.NewRGBA(image.Rect(0, 0, columns*cell.X, rows*cell.Y))
+		for n, src := range sources {
+			canvas := src.comp.Canvas()
+			at := image.Pt((n%columns)*cell.X, (n/columns)*cell.Y)
+			draw.Draw(combined, canvas.Bounds().Sub(canvas.Bounds().Min).Add(at), canvas, canvas.Bounds().Min, draw.Src)
+		}
+		if opts.dedupe && dedupe.duplicate(i, combined) {
+			continue
+		}
+
+		for _, v := range opts.formatVariants() {
+			name := opts.nameTemplate.render(baseFileName, outputIndices[i], v.frameExt(), max(delay, 0))
+			path := filepath.Join(outputDir, name)
+			if opts.skipExisting(path) {
+				continue
+			}
+			if err := saveFrame(path, v.process(combined), v, nil); err != nil {
+				return fmt.Errorf("saving frame %d: %w", i, err)
+			}
+			saved++
+			opts.infof("Saved frame %d as %s", i, name)
+		}
+	}
+	fmt.Printf("Successfully combined %d GIFs into %d image files (%s layout)\n", len(sources), saved, opts.combineLayout)
+	return nil
+}
+
+// 返回按合并后总帧数确定文件名补零宽度的选项
+func (o *options) forCombined(total int) *options {
+	t := o.nameTemplate.forFrameCount(total + o.indexOffset)
+	if t == o.nameTemplate {
+		return o
+	}
+	combinedOpts := *o
+	combinedOpts.nameTemplate = t
+	return &combinedOpts
+}
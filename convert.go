@@ -0,0 +1,798 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 转换单个 GIF 输入并写入 outputDir，"-" 表示从标准输入读取，HTTP(S) 地址会先下载。
+// ctx 取消时在当前帧完成后停止并返回错误
+func convertFile(ctx context.Context, input, outputDir string, opts *options) error {
+	decodeStart := time.Now()
+	gifImg, decoder, extensions, err := decodeInput(ctx, input, opts.lowMemory, opts.readExtensions)
+	opts.timer.since(stageDecode, decodeStart)
+	if err != nil {
+		return err
+	}
+
+	// 帧数超过 -max-frames 时拒绝处理，-truncate-frames 时只保留前面的帧
+	if opts.maxFrames > 0 && len(gifImg.Image) > opts.maxFrames {
+		if !opts.truncateFrames {
+			return fmt.Errorf("GIF has %d frames, more than -max-frames %d", len(gifImg.Image), opts.maxFrames)
+		}
+		log.Printf("Warning: %s has %d frames, converting only the first %d", input, len(gifImg.Image), opts.maxFrames)
+		truncateFrames(gifImg, opts.maxFrames)
+	}
+
+	// 确定需要输出的帧范围
+	var frames frameRange
+	if opts.singleFrame {
+		frames, err = newSingleFrameRange(opts.frameIndex, len(gifImg.Image))
+	} else if opts.at != nil {
+		frames = newTimeFrameRange(opts.at, gifImg)
+	} else {
+		frames, err = newFrameRange(opts.start, opts.end, opts.step, opts.every, len(gifImg.Image))
+	}
+	if err != nil {
+		return fmt.Errorf("invalid frame range: %w", err)
+	}
+	frames.offset = opts.indexOffset
+
+	// 裁剪区域完全落在画布之外时无法输出；在写出任何帧之前限制到画布范围内，
+	// 选项由多个文件共享，因此复制一份再修改
+	if !opts.crop.Empty() {
+		crop, err := gifconv.ClampCrop(opts.crop, gifconv.CanvasBounds(gifImg))
+		if err != nil {
+			return err
+		}
+		fileOpts := *opts
+		fileOpts.crop = crop
+		opts = &fileOpts
+	}
+
+	// -trim-uniform 先叠加一遍所有选中帧，将裁剪区域收紧到各帧内容的并集；
+	// 选项由多个文件共享，因此复制一份再修改
+	if opts.trimUniform {
+		content, err := contentBounds(ctx, newCompositor(gifImg, decoder), frames)
+		if err != nil {
+			return err
+		}
+		if !content.Empty() {
+			fileOpts := *opts
+			if opts.crop.Empty() {
+				fileOpts.crop = content
+			} else if fileOpts.crop, err = gifconv.ClampCrop(opts.crop, content); err != nil {
+				return err
+			}
+			opts = &fileOpts
+			opts.debugf("Trimming all frames to %v", opts.crop)
+		}
+	}
+
+	// 默认文件名中的帧序号按总帧数补零，1000 帧以上的 GIF 同样按文件名排序即为帧顺序
+	if t := opts.nameTemplate.forFrameCount(len(gifImg.Image) + opts.indexOffset); t != opts.nameTemplate {
+		fileOpts := *opts
+		fileOpts.nameTemplate = t
+		opts = &fileOpts
+	}
+	// 只有一帧的 GIF 按静态图像输出为 <base>.png，-force-frames 时保留带序号的文件名
+	if len(gifImg.Image) == 1 && !opts.forceFrames {
+		fileOpts := *opts
+		fileOpts.nameTemplate = opts.nameTemplate.forStaticImage()
+		fileOpts.staticImage = true
+		opts = &fileOpts
+	}
+	if opts.embedMetadata {
+		fileOpts := *opts
+		fileOpts.sourceName = inputSourceName(input)
+		opts = &fileOpts
+	}
+
+	// 获取输出文件的基本名称：优先使用 -basename，否则取输入文件名（不含扩展名）
+	baseFileName := opts.baseName
+	if baseFileName == "" {
+		baseFileName = inputBaseName(input)
+	}
+
+	if opts.every > 1 {
+		opts.infof("Keeping %d of %d frames (every %d)", frames.count(), len(gifImg.Image), opts.every)
+	}
+	if opts.at != nil {
+		opts.infof("Keeping %d of %d frames at %d timestamps", frames.count(), len(gifImg.Image), len(opts.at))
+	}
+
+	// 详细模式下列出选中帧的处置方法、范围与延迟
+	if opts.verbose {
+		meta := newGIFMetadata(gifImg)
+		for _, i := range frames.indices() {
+			frame := meta.Frames[i]
+			opts.debugf("Frame %d: disposal %s, bounds %v, delay %d", i, frame.Disposal, gifImg.Image[i].Bounds(), frame.Delay)
+		}
+	}
+
+	// 自动命名的输出目录已有文件时提醒，其中的同名文件会被覆盖
+	if opts.autoOutputDir {
+		if entries, err := os.ReadDir(outputDir); err == nil && len(entries) > 0 {
+			log.Printf("Warning: output directory %s already contains files", outputDir)
+		}
+	}
+
+	// 导出源调色板，与帧输出无关，因此仅演练时同样写出
+	if opts.exportPalette != "" && !opts.skipExisting(opts.exportPalette) {
+		palette, err := sourcePalette(gifImg, decoder)
+		if err == nil {
+			err = writePaletteFile(opts.exportPalette, baseFileName, palette)
+		}
+		if err != nil {
+			log.Printf("Error exporting palette: %v", err)
+		} else {
+			opts.infof("Saved %d-color palette as %s", len(palette), opts.exportPalette)
+		}
+	}
+
+	// 仅演练时只打印计划输出的文件，不写入磁盘
+	if opts.dryRun {
+		return printDryRun(gifImg, frames, outputDir, baseFileName, opts)
+	}
+
+	// 创建输出目录
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	// 写出帧时序文件
+	if opts.timingFile != "" && !opts.skipExisting(opts.timingFile) {
+		if err := writeTimingFile(opts.timingFile, gifImg, frames, opts.fps); err != nil {
+			log.Printf("Error writing timing file: %v", err)
+		} else {
+			opts.infof("Saved frame timing as %s", opts.timingFile)
+		}
+	}
+
+	// 写出时序汇总 CSV
+	if opts.timingCSV != "" && !opts.skipExisting(opts.timingCSV) {
+		if err := writeTimingCSV(ctx, opts.timingCSV, gifImg, newCompositor(gifImg, decoder), frames, opts); err != nil {
+			log.Printf("Error writing timing CSV: %v", err)
+		} else {
+			opts.infof("Saved timing summary as %s", opts.timingCSV)
+		}
+	}
+
+	// 写出元数据文件
+	if opts.metadata {
+		metaFileName := baseFileName + "_meta.json"
+		metaPath := filepath.Join(outputDir, metaFileName)
+		if !opts.skipExisting(metaPath) {
+			if err := writeMetadataFile(metaPath, gifImg, extensions, frames.offset); err != nil {
+				log.Printf("Error writing metadata: %v", err)
+			} else {
+				opts.infof("Saved metadata as %s", metaFileName)
+			}
+		}
+	}
+
+	// 所有帧共享同一画布，逐帧增量叠加
+	comp := newCompositor(gifImg, decoder)
+	switch {
+	case opts.outputFile != "":
+		return writeSingleFrame(ctx, comp, gifImg, frames, opts.outputFile, opts)
+	case opts.flattenFinal:
+		return writeSingleFrame(ctx, comp, gifImg, frames, filepath.Join(outputDir, baseFileName+"_final"+formatExt(opts.format)), opts)
+	case opts.thumbnail:
+		return writeSingleFrame(ctx, comp, gifImg, frames, filepath.Join(outputDir, baseFileName+formatExt(opts.format)), opts)
+	case opts.zipPath != "":
+		return writeZip(ctx, comp, gifImg, frames, baseFileName, opts)
+	case opts.dataURI != "":
+		return writeDataURIs(ctx, comp, frames, outputDir, baseFileName, opts)
+	case opts.diff:
+		return writeDiffs(ctx, comp, gifImg, frames, outputDir, baseFileName, opts)
+	case opts.montage:
+		return writeMontage(ctx, comp, frames, outputDir, baseFileName, opts)
+	case opts.spriteSheet:
+		return writeSpriteSheet(ctx, comp, frames, outputDir, baseFileName, opts)
+	case opts.format.animated():
+		return writeAnimation(ctx, comp, gifImg, frames, outputDir, baseFileName, opts)
+	case opts.multipage:
+		return writeMultipage(ctx, comp, frames, outputDir, baseFileName, opts)
+	default:
+		return writeFrames(ctx, comp, gifImg, frames, outputDir, baseFileName, opts)
+	}
+}
+
+// 创建叠加器，decoder 非空时逐帧解码像素
+func newCompositor(gifImg *gif.GIF, decoder *gifconv.FrameDecoder) *gifconv.Compositor {
+	if decoder != nil {
+		return gifconv.NewStreamingCompositor(gifImg, decoder)
+	}
+	return gifconv.NewCompositor(gifImg)
+}
+
+// 返回范围内各帧叠加结果中非透明内容范围的并集，所有帧完全透明时返回空矩形
+func contentBounds(ctx context.Context, comp *gifconv.Compositor, frames frameRange) (image.Rectangle, error) {
+	var union image.Rectangle
+	for {
+		if err := ctx.Err(); err != nil {
+			return image.Rectangle{}, err
+		}
+		i, err := comp.Advance()
+		if err == io.EOF {
+			return union, nil
+		}
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		if frames.contains(i) {
+			union = union.Union(gifconv.ContentBounds(comp.Canvas()))
+		}
+		if i >= frames.end {
+			return union, nil
+		}
+	}
+}
+
+// 读取并解码 GIF 输入，"-" 表示从标准输入读取，HTTP(S) 地址会先下载，gzip 压缩的数据会先解压。
+// lowMemory 时只扫描帧信息，返回的解码器在叠加时逐帧解码像素；
+// readExtensions 时同时返回 GIF 中的注释扩展与应用扩展
+func decodeInput(ctx context.Context, input string, lowMemory, readExtensions bool) (*gif.GIF, *gifconv.FrameDecoder, []gifconv.Extension, error) {
+	var src io.Reader = os.Stdin
+	if isURL(input) {
+		data, err := fetchURL(ctx, input)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		src = bytes.NewReader(data)
+	} else if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("opening GIF file: %w", err)
+		}
+		defer file.Close()
+		src = file
+	}
+	src, err := gunzipInput(src)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !lowMemory && !readExtensions {
+		gifImg, err := gifconv.DecodeGIF(src)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return gifImg, nil, nil, nil
+	}
+
+	// 逐帧解码与读取扩展块都需要完整的原始数据
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("reading GIF: %w", err)
+	}
+	decoder, gifImg, err := gifconv.NewFrameDecoder(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var extensions []gifconv.Extension
+	if readExtensions {
+		extensions = decoder.Extensions()
+	}
+	if !lowMemory {
+		// 扫描只用于读取扩展块，像素仍一次性解码
+		if gifImg, err = gifconv.DecodeGIF(bytes.NewReader(data)); err != nil {
+			return nil, nil, nil, err
+		}
+		decoder = nil
+	}
+	return gifImg, decoder, extensions, nil
+}
+
+// 以 gzip 魔数开头的输入（如 .gif.gz）先解压，其余数据原样读取，
+// 因此扩展名为 .gz 但内容未压缩的文件同样按普通 GIF 处理
+func gunzipInput(src io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(src)
+	if magic, _ := br.Peek(2); len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip input: %w", err)
+	}
+	return zr, nil
+}
+
+// 精灵图模式：收集所有选中帧后一次性编码
+func writeSpriteSheet(ctx context.Context, comp *gifconv.Compositor, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	sheetFrames, indices, err := collectFrames(ctx, comp, frames, opts)
+	if err != nil {
+		return err
+	}
+
+	sheetFrames, indices = reverseFrames(sheetFrames, indices, frames, opts)
+	sheet, placed := buildSpriteSheet(sheetFrames, indices, opts.columns)
+	sheetFileName := baseFileName + "_sheet" + formatExt(opts.format)
+	sheetPath := filepath.Join(outputDir, sheetFileName)
+	if opts.skipExisting(sheetPath) {
+		return nil
+	}
+	if err := saveFrame(sheetPath, sheet, opts, nil); err != nil {
+		return fmt.Errorf("saving sprite sheet: %w", err)
+	}
+	atlas := spriteAtlas{Image: sheetFileName, Width: sheet.Bounds().Dx(), Height: sheet.Bounds().Dy(), Frames: placed}
+	atlasPath := filepath.Join(outputDir, baseFileName+"_sheet.json")
+	if !opts.skipExisting(atlasPath) {
+		if err := writeAtlasFile(atlasPath, atlas); err != nil {
+			return fmt.Errorf("writing sprite sheet atlas: %w", err)
+		}
+	}
+	fmt.Printf("Successfully combined %d frames into %s\n", len(placed), sheetFileName)
+	return nil
+}
+
+// 预览图模式：所有选中帧排列成一张网格图像，便于快速浏览 GIF 的内容
+func writeMontage(ctx context.Context, comp *gifconv.Compositor, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	montageFileName := baseFileName + "_montage" + formatExt(opts.format)
+	montagePath := filepath.Join(outputDir, montageFileName)
+	if opts.skipExisting(montagePath) {
+		return nil
+	}
+
+	montageFrames, indices, err := collectFrames(ctx, comp, frames, opts)
+	if err != nil {
+		return err
+	}
+	montageFrames, indices = reverseFrames(montageFrames, indices, frames, opts)
+	montage := buildMontage(montageFrames, indices, opts.montageColumns, opts.montagePadding, opts.montageLabel)
+	if err := saveFrame(montagePath, montage, opts, nil); err != nil {
+		return fmt.Errorf("saving montage: %w", err)
+	}
+	fmt.Printf("Successfully combined %d frames into %s\n", len(indices), montageFileName)
+	return nil
+}
+
+// 动画模式：所有选中帧写入同一个 APNG 或动画 WebP 文件
+func writeAnimation(ctx context.Context, comp *gifconv.Compositor, gifImg *gif.GIF, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	animFileName := baseFileName + formatExt(opts.format)
+	animPath := filepath.Join(outputDir, animFileName)
+	if opts.skipExisting(animPath) {
+		return nil
+	}
+
+	animFrames, indices, err := collectFrames(ctx, comp, frames, opts)
+	if err != nil {
+		return err
+	}
+	delays := frames.mergedDelays(gifImg, indices)
+	if opts.reverse {
+		slices.Reverse(animFrames)
+		slices.Reverse(delays)
+	}
+	if opts.fps > 0 {
+		// 按固定帧率重新采样，重复的帧共用同一图像
+		positions, outDelays := resampleFPS(delays, opts.fps)
+		resampled := make([]image.Image, len(positions))
+		for k, n := range positions {
+			resampled[k] = animFrames[n]
+		}
+		opts.infof("Resampled %d frames to %d at %g fps", len(animFrames), len(resampled), opts.fps)
+		animFrames, delays = resampled, outDelays
+	}
+
+	if opts.format == FormatAWebP {
+		err = saveAnimatedWebP(animPath, animFrames, delays, gifImg.LoopCount, opts)
+	} else {
+		err = saveAPNG(animPath, animFrames, delays, gifImg.LoopCount)
+	}
+	if err != nil {
+		return fmt.Errorf("saving animation: %w", err)
+	}
+	fmt.Printf("Successfully converted GIF to %s with %d frames (%s)\n", animFileName, len(animFrames), loopSummary(gifImg.LoopCount))
+	return nil
+}
+
+// 只保留 GIF 的前 n 帧及其延迟与处置方法
+func truncateFrames(g *gif.GIF, n int) {
+	g.Image = g.Image[:n]
+	g.Delay = g.Delay[:min(n, len(g.Delay))]
+	g.Disposal = g.Disposal[:min(n, len(g.Disposal))]
+}
+
+// 单帧模式：将选中的一帧直接写入指定文件，不带帧序号后缀，path 为 "-" 时写入标准输出
+func writeSingleFrame(ctx context.Context, comp *gifconv.Compositor, gifImg *gif.GIF, frames frameRange, path string, opts *options) error {
+	if path != "-" && opts.skipExisting(path) {
+		return nil
+	}
+	images, indices, err := collectFrames(ctx, comp, frames, opts)
+	if err != nil {
+		return err
+	}
+	text := opts.frameText(indices[0], gifImg.Delay[indices[0]])
+	if path == "-" {
+		if err := encodeFrame(os.Stdout, images[0], opts, text); err != nil {
+			return fmt.Errorf("writing frame %d to stdout: %w", indices[0], err)
+		}
+		return nil
+	}
+	if err := saveFrame(path, images[0], opts, text); err != nil {
+		return fmt.Errorf("saving frame %d: %w", indices[0], err)
+	}
+	fmt.Printf("Saved frame %d as %s\n", indices[0], path)
+	return nil
+}
+
+// 多页模式：所有选中帧写入同一个 TIFF 或 PDF 文件，每帧一页
+func writeMultipage(ctx context.Context, comp *gifconv.Compositor, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	docFileName := baseFileName + formatExt(opts.format)
+	docPath := filepath.Join(outputDir, docFileName)
+	if opts.skipExisting(docPath) {
+		return nil
+	}
+
+	pages, _, err := collectFrames(ctx, comp, frames, opts)
+	if err != nil {
+		return err
+	}
+	if opts.reverse {
+		slices.Reverse(pages)
+	}
+	if opts.format == FormatPDF {
+		if err := savePDF(docPath, pages, opts.quality); err != nil {
+			return fmt.Errorf("saving PDF: %w", err)
+		}
+	} else if err := saveMultipageTIFF(docPath, pages, opts.tiffCompression); err != nil {
+		return fmt.Errorf("saving multi-page TIFF: %w", err)
+	}
+	fmt.Printf("Successfully converted GIF to %s with %d pages\n", docFileName, len(pages))
+	return nil
+}
+
+// 压缩包模式：各帧按文件名模板依次编码写入同一个 zip 文件，而非输出目录。
+// 编码失败时删除写了一半的压缩包，ctx 取消时保留已写入的帧
+func writeZip(ctx context.Context, comp *gifconv.Compositor, gifImg *gif.GIF, frames frameRange, baseFileName string, opts *options) error {
+	if opts.skipExisting(opts.zipPath) {
+		return nil
+	}
+	zipFile, err := createOutput(opts.zipPath)
+	if err != nil {
+		return fmt.Errorf("creating zip file: %w", err)
+	}
+	defer doneOutput(opts.zipPath)
+	zw := zip.NewWriter(zipFile)
+
+	ext := formatExt(opts.format)
+	prog := newProgress(frames.count(), opts)
+	if prog.inPlace {
+		log.SetOutput(prog)
+		defer log.SetOutput(os.Stderr)
+	}
+	outputIndices := frames.outputIndices(opts.reverse)
+	saved := 0
+	var encodeErr error
+	err = compositeFrames(ctx, comp, frames, opts, func(i int, img *image.RGBA) {
+		if encodeErr != nil {
+			return
+		}
+		delay := frames.delay(gifImg, i)
+		name := opts.nameTemplate.render(baseFileName, outputIndices[i], ext, delay)
+		entry, err := zw.Create(name)
+		if err == nil {
+			err = encodeFrame(entry, opts.process(img), opts, opts.frameText(i, delay))
+		}
+		if err != nil {
+			encodeErr = fmt.Errorf("writing frame %d to zip: %w", i, err)
+			return
+		}
+		saved++
+		prog.frameDone(fmt.Sprintf("Added frame %d as %s", i, name))
+	})
+	prog.finish()
+	if encodeErr != nil {
+		zipFile.Close()
+		os.Remove(opts.zipPath)
+		return encodeErr
+	}
+
+	if closeErr := zw.Close(); closeErr != nil {
+		zipFile.Close()
+		return fmt.Errorf("writing zip file: %w", closeErr)
+	}
+	if closeErr := zipFile.Close(); closeErr != nil {
+		return fmt.Errorf("writing zip file: %w", closeErr)
+	}
+	if err != nil {
+		fmt.Printf("Stopped after adding %d frames to %s\n", saved, opts.zipPath)
+		return err
+	}
+	fmt.Printf("Successfully converted GIF to %s with %d frames (%s)\n", opts.zipPath, saved, loopSummary(gifImg.LoopCount))
+	return nil
+}
+
+// 逐帧输出：叠加仍按顺序进行，编码与写入由多个协程并行完成。
+// 单帧写入失败时继续写出其余帧，最后汇总失败的帧并返回错误，-fail-fast 时在首个失败后停止；
+// ctx 取消后各协程完成当前帧即停止，尚未开始的帧不再写出
+func writeFrames(ctx context.Context, comp *gifconv.Compositor, gifImg *gif.GIF, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	stopCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	// 指定多种格式时每帧依次写出各格式的文件，叠加只进行一次
+	variants := opts.formatVariants()
+
+	// 启动编码协程
+	total := frames.count() * len(variants)
+	prog := newProgress(total, opts)
+	if prog.inPlace {
+		log.SetOutput(prog)
+		defer log.SetOutput(os.Stderr)
+	}
+	outputIndices := frames.outputIndices(opts.reverse)
+	jobs := make(chan frameJob, opts.workers)
+
+	// -html 预览页只引用实际存在的帧文件：已写出或因 -no-clobber 跳过的帧
+	var previewMu sync.Mutex
+	var preview []htmlFrame
+	addPreview := func(i int, file string, delay int) {
+		if opts.html {
+			previewMu.Lock()
+			preview = append(preview, htmlFrame{Index: i + frames.offset, File: file, Delay: delay * 10, order: outputIndices[i]})
+			previewMu.Unlock()
+		}
+	}
+	var saved, skipped atomic.Int64
+	frameFileName := func(i int, v *options) string {
+		return opts.nameTemplate.render(baseFileName, outputIndices[i], v.frameExt(), frames.delay(gifImg, i))
+	}
+
+	// -resume 时所有格式的输出都已存在的帧不再生成快照与编码，但仍参与叠加以保持处置方法正确；
+	// -dedupe 需要与上一输出帧比较，因此仍生成所有帧，只跳过写出
+	pending := frames
+	if opts.resume && !opts.dedupe {
+		done := make(map[int]bool)
+		for _, i := range frames.indices() {
+			done[i] = true
+			for _, v := range variants {
+				if !opts.existingOutput(filepath.Join(outputDir, frameFileName(i, v))) {
+					delete(done, i)
+					break
+				}
+			}
+			if !done[i] {
+				continue
+			}
+			for n, v := range variants {
+				opts.skipExisting(filepath.Join(outputDir, frameFileName(i, v)))
+				skipped.Add(1)
+				if n == 0 {
+					addPreview(i, frameFileName(i, v), frames.delay(gifImg, i))
+				}
+				prog.frameDone("")
+			}
+		}
+		pending = frames.without(done)
+	}
+
+	var failures frameErrors
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if stopCtx.Err() != nil {
+					continue
+				}
+				delay := frames.delay(gifImg, job.index)
+				for n, v := range variants {
+					seq := job.seq*len(variants) + n
+					outFileName := frameFileName(job.index, v)
+					outPath := filepath.Join(outputDir, outFileName)
+					if opts.existingOutput(outPath) {
+						skipped.Add(1)
+						if n == 0 {
+							addPreview(job.index, outFileName, delay)
+						}
+						prog.frameDoneAt(seq, frameMessage{warning: "Skipping existing file " + outPath})
+						continue
+					}
+					if err := saveFrame(outPath, v.process(job.img), v, opts.frameText(job.index, delay)); err != nil {
+						failures.add(job.index, err)
+						if opts.failFast {
+							stop()
+						}
+						prog.frameDoneAt(seq, frameMessage{})
+						continue
+					}
+					saved.Add(1)
+					if n == 0 {
+						addPreview(job.index, outFileName, delay)
+					}
+					prog.frameDoneAt(seq, frameMessage{text: fmt.Sprintf("Saved frame %d as %s", job.index, outFileName)})
+				}
+			}
+		}()
+	}
+
+	// 处理每一帧
+	var err error
+	if pending.count() > 0 {
+		seq := 0
+		err = compositeFrames(stopCtx, comp, pending, opts, func(i int, img *image.RGBA) {
+			jobs <- frameJob{index: i, seq: seq, img: img}
+			seq++
+		})
+	}
+	close(jobs)
+	wg.Wait()
+	prog.finish()
+
+	if n := skipped.Load(); n > 0 {
+		fmt.Printf("Skipped %d existing files\n", n)
+	}
+	failures.report()
+	if opts.html && len(preview) > 0 {
+		if err := writeHTMLPreview(filepath.Join(outputDir, "index.html"), baseFileName, preview); err != nil {
+			log.Printf("Error writing HTML preview: %v", err)
+		} else {
+			opts.infof("Saved HTML preview as index.html")
+		}
+	}
+
+	// -fail-fast 在首个失败后停止，返回该帧的错误
+	if opts.failFast && failures.count() > 0 {
+		fmt.Printf("Stopped after writing %d image files\n", saved.Load())
+		return failures.first()
+	}
+
+	// 叠加完成后才取消时，队列中剩余的帧同样被丢弃
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		fmt.Printf("Stopped after writing %d image files\n", saved.Load())
+		return err
+	}
+
+	// 单帧失败不中断其余帧，但整体以错误返回，使进程以非零状态退出
+	if n := failures.count(); n > 0 {
+		fmt.Printf("Converted GIF to %d image files, %d frames failed (%s)\n", saved.Load(), n, loopSummary(gifImg.LoopCount))
+		return fmt.Errorf("%d of %d image files failed to write", n, total)
+	}
+	if opts.staticImage {
+		fmt.Printf("Successfully converted GIF to %d image files (static image)\n", saved.Load())
+		return nil
+	}
+	fmt.Printf("Successfully converted GIF to %d image files (%s)\n", saved.Load(), loopSummary(gifImg.LoopCount))
+	return nil
+}
+
+// 叠加并处理所有选中帧，返回处理后的图像及其帧序号，供合并输出的模式使用
+func collectFrames(ctx context.Context, comp *gifconv.Compositor, frames frameRange, opts *options) ([]image.Image, []int, error) {
+	var images []image.Image
+	var indices []int
+	err := compositeFrames(ctx, comp, frames, opts, func(i int, img *image.RGBA) {
+		images = append(images, opts.process(img))
+		indices = append(indices, i)
+	})
+	return images, indices, err
+}
+
+// 将合并输出的帧序号换成输出序号（含 -index-offset），-reverse 时同时将帧按相反顺序排列
+func reverseFrames(images []image.Image, indices []int, frames frameRange, opts *options) ([]image.Image, []int) {
+	outputIndices := frames.outputIndices(opts.reverse)
+	numbered := make([]int, len(indices))
+	for n, i := range indices {
+		numbered[n] = outputIndices[i]
+	}
+	if opts.reverse {
+		slices.Reverse(images)
+		slices.Reverse(numbered)
+	}
+	return images, numbered
+}
+
+// 待编码的帧快照
+type frameJob struct {
+	index int
+	seq   int // 在本次写出的帧中的顺序，用于按帧顺序输出日志
+	img   *image.RGBA
+}
+
+// 逐帧输出中写入失败的帧及原因，可被多个编码协程并发追加
+type frameErrors struct {
+	mu   sync.Mutex
+	errs []frameError
+}
+
+type frameError struct {
+	index int
+	err   error
+}
+
+func (f *frameErrors) add(index int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, frameError{index: index, err: err})
+}
+
+func (f *frameErrors) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.errs)
+}
+
+// 返回帧序号最小的失败，包装为带帧序号的错误
+func (f *frameErrors) first() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e := slices.MinFunc(f.errs, func(a, b frameError) int { return a.index - b.index })
+	return fmt.Errorf("frame %d: %w", e.index, e.err)
+}
+
+// 按帧序号列出所有失败的帧及原因
+func (f *frameErrors) report() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.errs) == 0 {
+		return
+	}
+	slices.SortFunc(f.errs, func(a, b frameError) int { return a.index - b.index })
+	log.Printf("%d frames failed:", len(f.errs))
+	for _, e := range f.errs {
+		log.Printf("  frame %d: %v", e.index, e.err)
+	}
+}
+
+// 按顺序叠加帧，并将范围内各帧的快照交给 emit，范围之前的帧仍需叠加以保证处置方法正确。
+// 启用 -dedupe 时跳过与上一输出帧完全相同的帧（-dedupe-ssim 时为相似度超过阈值的帧）；ctx 取消时停止叠加并返回 ctx.Err()
+func compositeFrames(ctx context.Context, comp *gifconv.Compositor, frames frameRange, opts *options, emit func(i int, img *image.RGBA)) error {
+	dedupe := newDeduper(opts)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 生成完整帧图像
+		start := time.Now()
+		i, err := comp.Advance()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if frames.contains(i) {
+			img := comp.Snapshot()
+			opts.timer.since(stageComposite, start)
+			if !opts.dedupe || !dedupe.duplicate(i, img) {
+				emit(i, img)
+			}
+		}
+		if i >= frames.end {
+			return nil
+		}
+	}
+}
+
+// 描述 GIF 的循环次数，0 表示无限循环，-1 表示只播放一次
+func loopSummary(loopCount int) string {
+	switch plays := gifconv.PlayCount(loopCount); plays {
+	case 0:
+		return fmt.Sprintf("loops: %d, 0 means infinite", loopCount)
+	case 1:
+		return fmt.Sprintf("loops: %d, plays once", loopCount)
+	default:
+		return fmt.Sprintf("loops: %d, plays %d times", loopCount, plays)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 返回输出格式对应的 MIME 类型
+func formatMIME(format OutputFormat) string {
+	switch format {
+	case FormatJPG:
+		return "image/jpeg"
+	case FormatWebP:
+		return "image/webp"
+	case FormatBMP:
+		return "image/bmp"
+	case FormatTIFF:
+		return "image/tiff"
+	case FormatICO:
+		return "image/x-icon"
+	case FormatAVIF:
+		return "image/avif"
+	default:
+		return "image/png"
+	}
+}
+
+// -data-uri 输出文件名：lines 为每行一个的文本文件，json 为 JSON 字符串数组
+func dataURIFileName(baseFileName string, opts *options) string {
+	if opts.dataURI == "json" {
+		return baseFileName + "_frames.json"
+	}
+	return baseFileName + "_frames.txt"
+}
+
+// 数据 URI 模式：将选中帧编码为 base64 数据 URI，全部写入同一个文本或 JSON 文件
+func writeDataURIs(ctx context.Context, comp *gifconv.Compositor, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	uriFileName := dataURIFileName(baseFileName, opts)
+	uriPath := filepath.Join(outputDir, uriFileName)
+	if opts.skipExisting(uriPath) {
+		return nil
+	}
+
+	images, indices, err := collectFrames(ctx, comp, frames, opts)
+	if err != nil {
+		return err
+	}
+	images, indices = reverseFrames(images, indices, frames, opts)
+
+	prefix := "data:" + formatMIME(opts.format) + ";base64,"
+	uris := make([]string, len(images))
+	for n, img := range images {
+		var buf bytes.Buffer
+		if err := encodeFrame(&buf, img, opts, nil); err != nil {
+			return fmt.Errorf("encoding frame %d: %w", indices[n], err)
+		}
+		uris[n] = prefix + base64.StdEncoding.EncodeToString(buf.Bytes())
+	}
+
+	var data []byte
+	if opts.dataURI == "json" {
+		if data, err = json.MarshalIndent(uris, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(strings.Join(uris, "\n") + "\n")
+	}
+	if err := os.WriteFile(uriPath, data, 0644); err != nil {
+		return fmt.Errorf("writing data URIs: %w", err)
+	}
+	fmt.Printf("Successfully wrote %d frames as data URIs to %s\n", len(uris), uriFileName)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"hash/crc32"
+	"image"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 记录上一输出帧的像素，用于跳过完全相同（或 -dedupe-ssim 下足够相似）的连续帧
+type deduper struct {
+	sum  uint32
+	last *image.RGBA
+	// threshold 大于 0 时改为比较灰度图像的结构相似度，lastGray 为上一输出帧的灰度图像
+	threshold float64
+	lastGray  *image.Gray
+	debugf    func(format string, args ...any)
+}
+
+func newDeduper(opts *options) *deduper {
+	return &deduper{threshold: opts.dedupeSSIM, debugf: opts.debugf}
+}
+
+// 判断第 i 帧 img 是否与上一输出帧重复，不重复时将其记为上一输出帧。
+// 先比较 CRC32，仅在校验和相同时才逐字节比较
+func (d *deduper) duplicate(i int, img *image.RGBA) bool {
+	if d.threshold > 0 {
+		return d.similar(i, img)
+	}
+	sum := crc32.ChecksumIEEE(img.Pix)
+	if d.last != nil && sum == d.sum && img.Rect == d.last.Rect && bytes.Equal(img.Pix, d.last.Pix) {
+		return true
+	}
+	d.sum, d.last = sum, img
+	return false
+}
+
+// 判断 img 与上一输出帧的 SSIM 是否超过阈值，详细模式下打印计算结果
+func (d *deduper) similar(i int, img *image.RGBA) bool {
+	gray := gifconv.Grayscale(img)
+	if d.lastGray != nil {
+		ssim := gifconv.SSIM(d.lastGray, gray)
+		d.debugf("Frame %d: SSIM %.4f against the previous written frame", i, ssim)
+		if ssim > d.threshold {
+			return true
+		}
+	}
+	d.lastGray = gray
+	return false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 差异模式：对第 0 帧之后的每个选中帧，写出其与前一帧叠加结果之间的差异图像，
+// 文件名以 <base>_diff 为基本名称按文件名模板生成。前一帧不在选中范围内时同样参与比较，
+// 因此每张图像都如实反映该帧的处置与重绘区域
+func writeDiffs(ctx context.Context, comp *gifconv.Compositor, gifImg *gif.GIF, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	ext := formatExt(opts.format)
+	total := frames.count()
+	if frames.contains(0) {
+		total--
+	}
+	prog := newProgress(total, opts)
+	if prog.inPlace {
+		log.SetOutput(prog)
+		defer log.SetOutput(os.Stderr)
+	}
+	outputIndices := frames.outputIndices(opts.reverse)
+
+	saved := 0
+	var prev *image.RGBA
+	for {
+		if err := ctx.Err(); err != nil {
+			prog.finish()
+			fmt.Printf("Stopped after writing %d difference images\n", saved)
+			return err
+		}
+		i, err := comp.Advance()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			prog.finish()
+			return err
+		}
+		if i > 0 && frames.contains(i) {
+			delay := frames.delay(gifImg, i)
+			name := opts.nameTemplate.render(baseFileName+"_diff", outputIndices[i], ext, delay)
+			path := filepath.Join(outputDir, name)
+			if opts.skipExisting(path) {
+				prog.frameDone("")
+			} else if err := saveFrame(path, opts.process(gifconv.Diff(prev, comp.Canvas())), opts, opts.frameText(i, delay)); err != nil {
+				prog.finish()
+				return fmt.Errorf("saving difference image for frame %d: %w", i, err)
+			} else {
+				saved++
+				prog.frameDone(fmt.Sprintf("Saved difference of frame %d as %s", i, name))
+			}
+		}
+		if i >= frames.end {
+			break
+		}
+		prev = comp.Snapshot()
+	}
+	prog.finish()
+	fmt.Printf("Successfully wrote %d difference images\n", saved)
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	"path/filepath"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 列出本次转换将要写出的所有文件路径
+func planOutputs(gifImg *gif.GIF, frames frameRange, outputDir, baseFileName string, opts *options) []string {
+	var paths []string
+	if opts.timingFile != "" {
+		paths = append(paths, opts.timingFile)
+	}
+	if opts.timingCSV != "" {
+		paths = append(paths, opts.timingCSV)
+	}
+	if opts.metadata {
+		paths = append(paths, filepath.Join(outputDir, baseFileName+"_meta.json"))
+	}
+
+	ext := formatExt(opts.format)
+	outputIndices := frames.outputIndices(opts.reverse)
+	switch {
+	case opts.outputFile != "":
+		paths = append(paths, opts.outputFile)
+	case opts.flattenFinal:
+		paths = append(paths, filepath.Join(outputDir, baseFileName+"_final"+ext))
+	case opts.thumbnail:
+		paths = append(paths, filepath.Join(outputDir, baseFileName+ext))
+	case opts.zipPath != "":
+		paths = append(paths, opts.zipPath)
+	case opts.dataURI != "":
+		paths = append(paths, filepath.Join(outputDir, dataURIFileName(baseFileName, opts)))
+	case opts.diff:
+		for _, i := range frames.indices() {
+			if i > 0 {
+				paths = append(paths, filepath.Join(outputDir, opts.nameTemplate.render(baseFileName+"_diff", outputIndices[i], ext, frames.delay(gifImg, i))))
+			}
+		}
+	case opts.montage:
+		paths = append(paths, filepath.Join(outputDir, baseFileName+"_montage"+ext))
+	case opts.spriteSheet:
+		paths = append(paths,
+			filepath.Join(outputDir, baseFileName+"_sheet"+ext),
+			filepath.Join(outputDir, baseFileName+"_sheet.json"))
+	case opts.format.animated(), opts.multipage:
+		paths = append(paths, filepath.Join(outputDir, baseFileName+ext))
+	default:
+		for _, i := range frames.indices() {
+			for _, v := range opts.formatVariants() {
+				paths = append(paths, filepath.Join(outputDir, opts.nameTemplate.render(baseFileName, outputIndices[i], v.frameExt(), frames.delay(gifImg, i))))
+			}
+		}
+		if opts.html {
+			paths = append(paths, filepath.Join(outputDir, "index.html"))
+		}
+	}
+	return paths
+}
+
+// 打印演练结果：帧数、输出尺寸与计划写出的文件，裁剪区域落在画布之外时返回错误
+func printDryRun(gifImg *gif.GIF, frames frameRange, outputDir, baseFileName string, opts *options) error {
+	bounds := gifconv.CanvasBounds(gifImg)
+	if !opts.crop.Empty() {
+		var err error
+		if bounds, err = gifconv.ClampCrop(opts.crop, bounds); err != nil {
+			return err
+		}
+	}
+	size := bounds.Size()
+	if opts.rotate == 90 || opts.rotate == 270 {
+		size.X, size.Y = size.Y, size.X
+	}
+	if opts.rotateDeg != 0 {
+		size = gifconv.RotatedSize(size, opts.rotateDeg)
+	}
+	if opts.fitSize != (image.Point{}) {
+		size = opts.fitSize
+	}
+	if opts.width > 0 || opts.height > 0 {
+		size = gifconv.ResizeDimensions(size, opts.width, opts.height)
+	}
+	if opts.maxDimension > 0 {
+		size = gifconv.FitDimensions(size, opts.maxDimension)
+	}
+
+	paths := planOutputs(gifImg, frames, outputDir, baseFileName, opts)
+	for _, path := range paths {
+		fmt.Printf("Would write %s\n", path)
+	}
+	fmt.Printf("Dry run: %d of %d frames selected at %dx%d, %d files would be written (%s)\n",
+		frames.count(), len(gifImg.Image), size.X, size.Y, len(paths), loopSummary(gifImg.LoopCount))
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunSize(t *testing.T) {
+	// 演练报告的输出尺寸应与实际转换写出的尺寸一致；测试 GIF 为 16x8
+	dir := t.TempDir()
+	input := filepath.Join(dir, "anim.gif")
+	writeTestGIF(t, input, 2)
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "at 16x8,"},
+		{[]string{"-rotate", "90"}, "at 8x16,"},
+		{[]string{"-rotate-deg", "45"}, "at 17x17,"},
+		{[]string{"-rotate-deg", "-90"}, "at 8x16,"},
+		{[]string{"-rotate-deg", "30"}, "at 18x15,"},
+		{[]string{"-fit-size", "100x100"}, "at 100x100,"},
+		{[]string{"-rotate", "90", "-fit-size", "30x20"}, "at 30x20,"},
+	}
+	for _, tt := range tests {
+		args := append([]string{"-dry-run", "-output", filepath.Join(dir, "out")}, tt.args...)
+		stdout := runMain(t, append(args, input)...)
+		if !strings.Contains(stdout, tt.want) {
+			t.Errorf("gif2png %s: want %q in\n%s", strings.Join(tt.args, " "), tt.want, stdout)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"time"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 返回输出格式对应的文件扩展名
+func formatExt(format OutputFormat) string {
+	switch format {
+	case FormatJPG:
+		return ".jpg"
+	case FormatWebP, FormatAWebP:
+		return ".webp"
+	case FormatAPNG:
+		return ".apng"
+	case FormatBMP:
+		return ".bmp"
+	case FormatTIFF:
+		return ".tiff"
+	case FormatICO:
+		return ".ico"
+	case FormatAVIF:
+		return ".avif"
+	case FormatPDF:
+		return ".pdf"
+	default:
+		return ".png"
+	}
+}
+
+// 将所有帧写为 APNG 动画文件，编码失败时删除写了一半的文件
+func saveAPNG(path string, frames []image.Image, delays []int, loopCount int) error {
+	outFile, err := createOutput(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer doneOutput(path)
+	if err := gifconv.EncodeAPNG(outFile, frames, delays, loopCount); err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return err
+	}
+	return outFile.Close()
+}
+
+// 将多帧写为一个多页 TIFF 文件，编码失败时删除写了一半的文件
+func saveMultipageTIFF(path string, pages []image.Image, compression gifconv.TIFFCompression) error {
+	outFile, err := createOutput(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer doneOutput(path)
+	if err := gifconv.EncodeTIFF(outFile, pages, compression); err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return err
+	}
+	return outFile.Close()
+}
+
+// 将多帧写为 PDF 文档，每帧一页，编码失败时删除写了一半的文件
+func savePDF(path string, pages []image.Image, quality int) error {
+	outFile, err := createOutput(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer doneOutput(path)
+	if err := gifconv.EncodePDF(outFile, pages, quality); err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return err
+	}
+	return outFile.Close()
+}
+
+// 返回 -embed-metadata 时写入第 i 帧的文本元数据，delay 为该帧的持续时间（1/100 秒），未设置时为 nil
+func (o *options) frameText(i, delay int) []gifconv.TextEntry {
+	if !o.embedMetadata {
+		return nil
+	}
+	return []gifconv.TextEntry{
+		{Key: "Source", Value: o.sourceName},
+		{Key: "Frame", Value: fmt.Sprint(i)},
+		{Key: "Delay", Value: fmt.Sprintf("%d ms", delay*10)},
+		{Key: "Software", Value: "gif2png"},
+	}
+}
+
+// 按选项中的格式将单帧编码并写入文件，text 不为空时嵌入文本元数据，编码失败时删除写了一半的文件
+func saveFrame(path string, img image.Image, opts *options, text []gifconv.TextEntry) error {
+	outFile, err := createOutput(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer doneOutput(path)
+	if opts.timer != nil {
+		return saveFrameTimed(outFile, path, img, opts, text)
+	}
+	if err := encodeFrame(outFile, img, opts, text); err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return err
+	}
+	return outFile.Close()
+}
+
+// -profile 时先编码到内存再写入文件，分别计入编码与写入阶段
+func saveFrameTimed(outFile *os.File, path string, img image.Image, opts *options, text []gifconv.TextEntry) error {
+	start := time.Now()
+	var buf bytes.Buffer
+	err := encodeFrame(&buf, img, opts, text)
+	opts.timer.since(stageEncode, start)
+	if err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return err
+	}
+	defer opts.timer.since(stageWrite, time.Now())
+	if _, err := buf.WriteTo(outFile); err != nil {
+		outFile.Close()
+		os.Remove(path)
+		return err
+	}
+	return outFile.Close()
+}
+
+// 按选项中的格式将单帧编码写入 w，text 不为空时嵌入文本元数据
+func encodeFrame(w io.Writer, img image.Image, opts *options, text []gifconv.TextEntry) error {
+	if len(text) > 0 {
+		w = gifconv.EmbedText(w, text)
+	}
+	return opts.converter.Encode(w, img)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// 下载的 GIF 整个读入内存，超过该大小的响应视为错误，避免异常的服务器耗尽内存
+var maxFetchSize int64 = 256 << 20
+
+// 判断输入是否为 HTTP(S) 地址
+func isURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// 下载 URL 的内容，跟随重定向，非 200 响应或超过 maxFetchSize 的响应返回错误；ctx 同时限制下载时间
+func fetchURL(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", rawURL, resp.Status)
+	}
+	// 多读一个字节以判断响应是否超出上限
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+	if int64(len(data)) > maxFetchSize {
+		return nil, fmt.Errorf("fetching %s: response larger than %d bytes", rawURL, maxFetchSize)
+	}
+	return data, nil
+}
+
+// 由 URL 路径的最后一段得到输出文件的基本名称，无法确定时为 "download"
+func urlBaseName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "download"
+	}
+	base := trimGzipExt(path.Base(u.Path))
+	base = strings.TrimSuffix(base, path.Ext(base))
+	if base == "" || base == "." || base == "/" {
+		return "download"
+	}
+	return base
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchURLLimit(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	defer func(n int64) { maxFetchSize = n }(maxFetchSize)
+	maxFetchSize = 100
+	data, err := fetchURL(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("fetchURL at the limit: %v", err)
+	}
+	if string(data) != body {
+		t.Errorf("got %d bytes, want %d", len(data), len(body))
+	}
+
+	maxFetchSize = 99
+	if _, err := fetchURL(context.Background(), srv.URL); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("fetchURL over the limit: err = %v, want a size error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"image/gif"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 需要输出的帧范围，end 为闭区间；every 按整个动画的帧序号抽取，与 step 同时生效。
+// selected 非空时只输出其中的帧（-at）；offset 加到文件名、时序与元数据中的帧序号上（-index-offset）
+type frameRange struct {
+	start, end, step, every int
+	selected                map[int]bool
+	offset                  int
+}
+
+// 校验并解析帧范围，end 为 -1 表示最后一帧
+func newFrameRange(start, end, step, every, count int) (frameRange, error) {
+	if end == -1 {
+		end = count - 1
+	}
+	switch {
+	case step < 1:
+		return frameRange{}, fmt.Errorf("step must be at least 1, got %d", step)
+	case every < 1:
+		return frameRange{}, fmt.Errorf("every must be at least 1, got %d", every)
+	case start < 0 || start >= count:
+		return frameRange{}, fmt.Errorf("start %d out of range [0, %d]", start, count-1)
+	case end < start || end >= count:
+		return frameRange{}, fmt.Errorf("end %d out of range [%d, %d]", end, start, count-1)
+	}
+	r := frameRange{start: start, end: end, step: step, every: every}
+	if r.count() == 0 {
+		return frameRange{}, fmt.Errorf("no frames selected between %d and %d with step %d and every %d", start, end, step, every)
+	}
+	return r, nil
+}
+
+// 只包含单独一帧的范围，负数序号从末尾倒数
+func newSingleFrameRange(index, count int) (frameRange, error) {
+	if index < 0 {
+		index += count
+	}
+	if index < 0 || index >= count {
+		return frameRange{}, fmt.Errorf("frame %d out of range [%d, %d]", index, -count, count-1)
+	}
+	return frameRange{start: index, end: index, step: 1, every: 1}, nil
+}
+
+// 解析以逗号分隔的时间点（秒），如 "0.5,1.0,2.0"
+func parseTimestamps(s string) ([]float64, error) {
+	var times []float64
+	for _, part := range strings.Split(s, ",") {
+		t, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || t < 0 || math.IsNaN(t) || math.IsInf(t, 0) {
+			return nil, fmt.Errorf("invalid timestamp %q, expected non-negative seconds", part)
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// 只包含各时间点（秒）上正在显示的帧的范围，超过动画总时长的时间点取最后一帧。
+// 多个时间点落在同一帧时该帧只输出一次
+func newTimeFrameRange(times []float64, g *gif.GIF) frameRange {
+	// starts[i] 为第 i 帧开始显示的时间（1/100 秒）
+	starts := make([]int, len(g.Image))
+	for i := 1; i < len(starts); i++ {
+		starts[i] = starts[i-1]
+		if i-1 < len(g.Delay) {
+			starts[i] += g.Delay[i-1]
+		}
+	}
+	r := frameRange{start: len(starts) - 1, step: 1, every: 1, selected: make(map[int]bool)}
+	for _, t := range times {
+		cs := int(math.Round(t * 100))
+		// 最后一个开始时间不晚于 t 的帧，延迟为 0 的帧会被其后的帧覆盖
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > cs }) - 1
+		r.selected[i] = true
+		r.start = min(r.start, i)
+		r.end = max(r.end, i)
+	}
+	return r
+}
+
+// 判断第 i 帧是否需要输出
+func (r frameRange) contains(i int) bool {
+	if r.selected != nil && !r.selected[i] {
+		return false
+	}
+	return i >= r.start && i <= r.end && (i-r.start)%r.step == 0 && i%r.every == 0
+}
+
+// 按顺序返回范围内需要输出的帧序号
+func (r frameRange) indices() []int {
+	var indices []int
+	for i := r.start; i <= r.end; i += r.step {
+		if r.contains(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// 返回去掉 done 中各帧后的范围，end 收紧到最后一个剩余帧，使叠加可以提前结束
+func (r frameRange) without(done map[int]bool) frameRange {
+	rest := frameRange{start: r.start, end: r.start - 1, step: r.step, every: r.every, selected: make(map[int]bool), offset: r.offset}
+	for _, i := range r.indices() {
+		if !done[i] {
+			rest.selected[i] = true
+			rest.end = i
+		}
+	}
+	return rest
+}
+
+// 返回范围内需要输出的帧数
+func (r frameRange) count() int {
+	return len(r.indices())
+}
+
+// 返回第 i 帧在输出序列中持续的时间（1/100 秒），包含直到下一输出帧之前被跳过的帧
+func (r frameRange) delay(g *gif.GIF, i int) int {
+	total := 0
+	for j := i; j <= r.end && j < len(g.Delay); j++ {
+		if j > i && r.contains(j) {
+			break
+		}
+		total += g.Delay[j]
+	}
+	return total
+}
+
+// 返回 indices 中各输出帧持续的时间（1/100 秒），
+// 被 -dedupe 跳过的帧的时间并入其之前保留的帧
+func (r frameRange) mergedDelays(g *gif.GIF, indices []int) []int {
+	delays := make([]int, len(indices))
+	for n, i := range indices {
+		next := r.end + 1
+		if n+1 < len(indices) {
+			next = indices[n+1]
+		}
+		for j := i; j < next; j++ {
+			if r.contains(j) {
+				delays[n] += r.delay(g, j)
+			}
+		}
+	}
+	return delays
+}
+
+// 返回帧序号到输出序号的映射：reverse 时第一个输出帧与最后一个输出帧互换序号，
+// 依此类推，否则输出序号即帧序号；两种情况都加上 offset
+func (r frameRange) outputIndices(reverse bool) map[int]int {
+	indices := r.indices()
+	mapping := make(map[int]int, len(indices))
+	for n, i := range indices {
+		if reverse {
+			mapping[i] = indices[len(indices)-1-n] + r.offset
+		} else {
+			mapping[i] = i + r.offset
+		}
+	}
+	return mapping
+}
+
+// 按固定帧率 fps 重新采样 delays（1/100 秒）描述的帧序列：返回每个输出帧对应的
+// 输入帧位置及其延迟，按累计时间重复或丢弃帧，延迟的舍入误差不会累积
+func resampleFPS(delays []int, fps float64) ([]int, []int) {
+	starts := make([]int, len(delays))
+	total := 0
+	for n, d := range delays {
+		starts[n] = total
+		total += d
+	}
+	count := max(1, int(math.Round(float64(total)*fps/100)))
+	positions := make([]int, count)
+	outDelays := make([]int, count)
+	for k := range positions {
+		t := float64(k) * 100 / fps
+		// 最后一个开始时间不晚于 t 的帧
+		positions[k] = sort.Search(len(starts), func(n int) bool { return float64(starts[n]) > t }) - 1
+		outDelays[k] = int(math.Round(float64(k+1)*100/fps)) - int(math.Round(float64(k)*100/fps))
+	}
+	return positions, outDelays
+}
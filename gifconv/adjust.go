@@ -0,0 +1,65 @@
+package gifconv
+
+import (
+	"image"
+	"math"
+)
+
+// AdjustLevel 调整单个非预乘颜色分量：以 0.5 为中心按 contrast 倍拉伸后加上 brightness，
+// 两者以 0-1 的分量范围计算，结果截断到 0-255。brightness 为 0 且 contrast 为 1 时原样返回
+func AdjustLevel(v uint8, brightness, contrast float64) uint8 {
+	f := (float64(v)/255-0.5)*contrast + 0.5 + brightness
+	return uint8(math.Round(math.Max(0, math.Min(1, f)) * 255))
+}
+
+// Adjust 对图像的 R、G、B 分量应用亮度与对比度调整，透明通道保持不变。
+// 半透明像素先还原为非预乘颜色再调整，避免 alpha 参与计算
+func Adjust(src image.Image, brightness, contrast float64) *image.RGBA {
+	var table [256]uint8
+	for v := range table {
+		table[v] = AdjustLevel(uint8(v), brightness, contrast)
+	}
+	return applyLevels(src, &table)
+}
+
+// GammaTable 预先计算的伽马校正查找表，构建一次后可用于任意多帧
+type GammaTable [256]uint8
+
+// NewGammaTable 构建伽马校正查找表：输出为 255·(v/255)^(1/gamma)，
+// gamma 大于 1 时提亮中间调，小于 1 时压暗，0 与 255 保持不变
+func NewGammaTable(gamma float64) *GammaTable {
+	var t GammaTable
+	for v := range t {
+		t[v] = uint8(math.Round(math.Pow(float64(v)/255, 1/gamma) * 255))
+	}
+	return &t
+}
+
+// Apply 对图像的 R、G、B 分量查表进行伽马校正，透明通道保持不变
+func (t *GammaTable) Apply(src image.Image) *image.RGBA {
+	return applyLevels(src, (*[256]uint8)(t))
+}
+
+// 按查找表逐分量映射非预乘颜色，完全不透明的像素直接查表，
+// 半透明像素先还原为非预乘颜色，查表后重新预乘
+func applyLevels(src image.Image, table *[256]uint8) *image.RGBA {
+	in := toRGBA(src)
+	dst := image.NewRGBA(in.Rect)
+	for i := 0; i+3 < len(in.Pix); i += 4 {
+		a := in.Pix[i+3]
+		dst.Pix[i+3] = a
+		switch a {
+		case 0:
+		case 0xff:
+			for c := 0; c < 3; c++ {
+				dst.Pix[i+c] = table[in.Pix[i+c]]
+			}
+		default:
+			for c := 0; c < 3; c++ {
+				v := table[(int(in.Pix[i+c])*0xff+int(a)/2)/int(a)]
+				dst.Pix[i+c] = uint8((int(v)*int(a) + 0x7f) / 0xff)
+			}
+		}
+	}
+	return dst
+}
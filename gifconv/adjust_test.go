@@ -0,0 +1,76 @@
+package gifconv
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestAdjustLevelIdentity(t *testing.T) {
+	// brightness 为 0、contrast 为 1 时对所有分量值原样返回
+	for v := 0; v < 256; v++ {
+		if got := AdjustLevel(uint8(v), 0, 1); got != uint8(v) {
+			t.Errorf("AdjustLevel(%d, 0, 1) = %d", v, got)
+		}
+	}
+}
+
+func TestAdjustLevel(t *testing.T) {
+	tests := []struct {
+		v                    uint8
+		brightness, contrast float64
+		want                 uint8
+	}{
+		{0, 0, 1, 0},
+		{255, 0, 1, 255},
+		{0, 1, 1, 255},
+		{200, 1, 1, 255},
+		{255, -1, 1, 0},
+		{50, -1, 1, 0},
+		{127, 0, 1000, 0},
+		{128, 0, 1000, 255},
+		{0, 0, 1000, 0},
+		{255, 0, 1000, 255},
+		{10, 0, 0, 128},
+		{250, 0, 0, 128},
+		{100, 10, 10, 255},
+		{100, -10, 10, 0},
+	}
+	for _, tt := range tests {
+		if got := AdjustLevel(tt.v, tt.brightness, tt.contrast); got != tt.want {
+			t.Errorf("AdjustLevel(%d, %g, %g) = %d, want %d", tt.v, tt.brightness, tt.contrast, got, tt.want)
+		}
+	}
+}
+
+func TestAdjustPreservesAlpha(t *testing.T) {
+	// 半透明像素的 alpha 不变，颜色按非预乘值调整后重新预乘
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{200, 100, 50, 128})
+	src.SetNRGBA(1, 0, color.NRGBA{10, 240, 128, 1})
+	const brightness, contrast = 0.1, 1.5
+	dst := Adjust(src, brightness, contrast)
+	for x := 0; x < 2; x++ {
+		in := src.NRGBAAt(x, 0)
+		got := color.NRGBAModel.Convert(dst.RGBAAt(x, 0)).(color.NRGBA)
+		if got.A != in.A {
+			t.Errorf("pixel %d: alpha = %d, want %d", x, got.A, in.A)
+		}
+		if x == 1 {
+			// alpha 为 1 时预乘后颜色精度不足，只检查 alpha
+			continue
+		}
+		want := color.NRGBA{
+			AdjustLevel(in.R, brightness, contrast),
+			AdjustLevel(in.G, brightness, contrast),
+			AdjustLevel(in.B, brightness, contrast),
+			in.A,
+		}
+		for _, d := range []int{int(got.R) - int(want.R), int(got.G) - int(want.G), int(got.B) - int(want.B)} {
+			if d < -2 || d > 2 {
+				t.Errorf("pixel %d: got %v, want about %v", x, got, want)
+				break
+			}
+		}
+	}
+}
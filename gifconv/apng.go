@@ -0,0 +1,131 @@
+package gifconv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// EncodeAPNG 将所有帧写为一个 APNG 动画。delays 为每帧延迟（1/100 秒），
+// loopCount 采用 image/gif 的语义：0 表示无限循环，-1 表示只播放一次。
+// 所有帧必须具有相同尺寸，某一帧无法编码时返回 *EncodeError。
+func EncodeAPNG(w io.Writer, frames []image.Image, delays []int, loopCount int) error {
+	if len(frames) == 0 {
+		return ErrNoFrames
+	}
+	size := frames[0].Bounds().Size()
+
+	plays := PlayCount(loopCount)
+
+	e := &apngEncoder{w: w}
+	e.write(pngSignature)
+
+	// IHDR：8 位 RGBA，无隔行扫描
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(size.X))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(size.Y))
+	ihdr[8] = 8
+	ihdr[9] = 6
+	e.chunk("IHDR", ihdr)
+
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(plays))
+	e.chunk("acTL", actl)
+
+	for i, frame := range frames {
+		if frame.Bounds().Size() != size {
+			return &EncodeError{Frame: i, Err: errors.New("gifconv: APNG frames must all have the same size")}
+		}
+		delay := 0
+		if i < len(delays) {
+			delay = delays[i]
+		}
+
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], e.nextSeq())
+		binary.BigEndian.PutUint32(fctl[4:], uint32(size.X))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(size.Y))
+		// delay_num 只有 16 位，超出的延迟截断为最大值而不是回绕
+		binary.BigEndian.PutUint16(fctl[20:], uint16(min(delay, 0xffff)))
+		binary.BigEndian.PutUint16(fctl[22:], 100)
+		// dispose_op 与 blend_op 均为 0：不处置、直接覆盖，每帧都是完整画面
+		e.chunk("fcTL", fctl)
+
+		data, err := compressRGBA(frame)
+		if err != nil {
+			return &EncodeError{Frame: i, Err: err}
+		}
+		if i == 0 {
+			e.chunk("IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, e.nextSeq())
+			e.chunk("fdAT", append(fdat, data...))
+		}
+	}
+
+	e.chunk("IEND", nil)
+	return e.err
+}
+
+// 逐块写出 PNG 数据，记录第一个写入错误
+type apngEncoder struct {
+	w   io.Writer
+	seq uint32
+	err error
+}
+
+func (e *apngEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+func (e *apngEncoder) chunk(name string, data []byte) {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], name)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+
+	e.write(header)
+	e.write(data)
+	e.write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+}
+
+func (e *apngEncoder) nextSeq() uint32 {
+	seq := e.seq
+	e.seq++
+	return seq
+}
+
+// 将图像按行（过滤类型 0）压缩为 8 位非预乘 RGBA 数据
+func compressRGBA(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 1+4*bounds.Dx())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			off := 1 + 4*(x-bounds.Min.X)
+			row[off], row[off+1], row[off+2], row[off+3] = c.R, c.G, c.B, c.A
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
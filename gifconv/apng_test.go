@@ -0,0 +1,30 @@
+package gifconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+func TestEncodeAPNGDelayClamp(t *testing.T) {
+	// 超过 65535 的延迟截断为 0xffff，不会回绕成很短的延迟
+	frames := []image.Image{image.NewRGBA(image.Rect(0, 0, 2, 2)), image.NewRGBA(image.Rect(0, 0, 2, 2))}
+	delays := []int{70000, 150}
+	var buf bytes.Buffer
+	if err := EncodeAPNG(&buf, frames, delays, 0); err != nil {
+		t.Fatalf("EncodeAPNG: %v", err)
+	}
+	var got []uint16
+	data := buf.Bytes()[8:]
+	for len(data) >= 12 {
+		n := binary.BigEndian.Uint32(data)
+		if string(data[4:8]) == "fcTL" {
+			got = append(got, binary.BigEndian.Uint16(data[8+20:]))
+		}
+		data = data[12+n:]
+	}
+	if len(got) != 2 || got[0] != 0xffff || got[1] != 150 {
+		t.Errorf("fcTL delays = %v, want [65535 150]", got)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build avif
+
+package gifconv
+
+import (
+	"image"
+	"io"
+
+	"github.com/Kagami/go-avif"
+)
+
+// AVIFSupported 表示本次构建是否包含 AVIF 编码器（需以 -tags avif 构建并安装 libaom）
+const AVIFSupported = true
+
+// 将单帧编码为 AVIF：quality 1-100 映射到 libaom 的量化参数 63-0，
+// lossless 时使用量化参数 0 与 4:4:4 色度采样
+func encodeAVIF(w io.Writer, img image.Image, quality int, lossless bool) error {
+	opts := &avif.Options{
+		Speed:   avif.MaxSpeed / 2,
+		Quality: avif.MaxQuality - (quality-1)*avif.MaxQuality/99,
+	}
+	if lossless {
+		ratio := image.YCbCrSubsampleRatio444
+		opts.Quality = avif.MinQuality
+		opts.SubsampleRatio = &ratio
+	}
+	return avif.Encode(w, img, opts)
+}
@@ -0,0 +1,18 @@
+//go:build !avif
+
+package gifconv
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// AVIFSupported 表示本次构建是否包含 AVIF 编码器（需以 -tags avif 构建并安装 libaom）
+const AVIFSupported = false
+
+var errNoAVIF = errors.New("gifconv: AVIF support not built in (rebuild with -tags avif)")
+
+func encodeAVIF(w io.Writer, img image.Image, quality int, lossless bool) error {
+	return errNoAVIF
+}
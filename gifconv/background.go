@@ -0,0 +1,100 @@
+package gifconv
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ParseHexColor 解析 #rrggbb 或 #rgb 形式的颜色，# 可省略
+func ParseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("gifconv: invalid color %q", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("gifconv: invalid color %q", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Flatten 将图像叠加到纯色背景上，返回不含透明像素的新图像
+func Flatten(src image.Image, bg color.Color) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Over)
+	return dst
+}
+
+// BackgroundFit 背景图像与帧尺寸不同时的铺放方式
+type BackgroundFit int
+
+const (
+	BackgroundStretch BackgroundFit = iota // 缩放到与帧相同的尺寸，不保持宽高比
+	BackgroundTile                         // 按原尺寸从左上角开始平铺
+	BackgroundCover                        // 保持宽高比缩放到完全覆盖帧，居中裁掉多余部分
+)
+
+// ParseBackgroundFit 解析铺放方式名称：stretch、tile 或 cover
+func ParseBackgroundFit(name string) (BackgroundFit, error) {
+	switch name {
+	case "stretch":
+		return BackgroundStretch, nil
+	case "tile":
+		return BackgroundTile, nil
+	case "cover":
+		return BackgroundCover, nil
+	default:
+		return 0, fmt.Errorf("gifconv: unknown background fit %q", name)
+	}
+}
+
+// BackgroundLayer 生成 size 大小的背景图层：先填充 bg（为 nil 时保持透明），再按 fit 绘制背景图像
+func BackgroundLayer(img image.Image, size image.Point, fit BackgroundFit, bg color.Color) *image.RGBA {
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	if bg != nil {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	}
+	src := img.Bounds()
+	if src.Empty() {
+		return dst
+	}
+	switch fit {
+	case BackgroundTile:
+		for y := 0; y < size.Y; y += src.Dy() {
+			for x := 0; x < size.X; x += src.Dx() {
+				draw.Draw(dst, image.Rect(x, y, x+src.Dx(), y+src.Dy()), img, src.Min, draw.Over)
+			}
+		}
+	case BackgroundCover:
+		// 取使两边都不小于帧的缩放比例，超出部分从两侧均匀裁掉
+		scaled := image.Pt(size.X, max(1, (src.Dy()*size.X+src.Dx()/2)/src.Dx()))
+		if scaled.Y < size.Y {
+			scaled = image.Pt(max(1, (src.Dx()*size.Y+src.Dy()/2)/src.Dy()), size.Y)
+		}
+		offset := image.Pt((size.X-scaled.X)/2, (size.Y-scaled.Y)/2)
+		xdraw.CatmullRom.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(scaled)}, img, src, xdraw.Over, nil)
+	default:
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src, xdraw.Over, nil)
+	}
+	return dst
+}
+
+// FlattenOnto 将图像叠加到 BackgroundLayer 生成的背景图层上，layer 的尺寸应与图像相同
+func FlattenOnto(src image.Image, layer *image.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, layer, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,86 @@
+package gifconv
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// RGBToCMYK 按未经校色的简单公式将 RGB 转换为 CMYK：K = 1 - max(R, G, B)，
+// 其余三色为去掉黑色后的补色（完全底色去除）。没有使用 ICC 配置文件，
+// 印刷前如需准确的颜色应在排版软件中按实际纸张与油墨重新转换
+func RGBToCMYK(src image.Image) *image.CMYK {
+	b := src.Bounds()
+	dst := image.NewCMYK(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			// 透明像素按白色纸张处理：预乘的颜色值叠加在白色上
+			r, g, bl, a := src.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			r, g, bl = r+0xffff-a, g+0xffff-a, bl+0xffff-a
+			c, m, yy, k := color.RGBToCMYK(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = c, m, yy, k
+		}
+	}
+	return dst
+}
+
+// EncodeJPEGCMYK 将图像按 RGBToCMYK 转换后编码为四通道的 CMYK 基线 JPEG，各通道不做子采样。
+// 与 Photoshop 写出的文件一样带有 transform 为 0 的 Adobe APP14 段，通道值按 Adobe 的惯例
+// 反相存储（255 表示无油墨），image/jpeg 等解码器据此还原为 CMYK
+func EncodeJPEGCMYK(w io.Writer, m image.Image, quality int) error {
+	b := m.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() >= 1<<16 || b.Dy() >= 1<<16 {
+		return fmt.Errorf("gifconv: JPEG image size %dx%d out of range", b.Dx(), b.Dy())
+	}
+	cmyk, ok := m.(*image.CMYK)
+	if !ok {
+		cmyk = RGBToCMYK(m)
+	}
+
+	e := &jpegEncoder{w: bufio.NewWriter(w)}
+	e.initQuant(quality)
+	size := b.Size()
+	e.write(0xff, 0xd8)
+	// Adobe APP14：版本 100，两组标志为 0，transform 0 表示通道未经颜色变换
+	e.write(0xff, 0xee, 0, 14, 'A', 'd', 'o', 'b', 'e', 0, 100, 0, 0, 0, 0, 0)
+	e.writeQuantTables()
+	e.write(0xff, 0xc0, 0, 8+4*3, 8,
+		byte(size.Y>>8), byte(size.Y), byte(size.X>>8), byte(size.X), 4,
+		1, 0x11, 0,
+		2, 0x11, 0,
+		3, 0x11, 0,
+		4, 0x11, 0)
+	e.writeHuffmanTables()
+	e.write(0xff, 0xda, 0, 14, 4, 1, 0x00, 2, 0x00, 3, 0x00, 4, 0x00, 0, 63, 0)
+	e.writeCMYKScan(cmyk)
+	e.emit(0x7f, 7) // 用 1 填充最后一个字节
+	e.write(0xff, 0xd9)
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// 按 MCU 顺序写出四个通道的扫描数据，各通道都使用亮度的量化表与 Huffman 表，
+// 超出图像的部分重复边缘像素
+func (e *jpegEncoder) writeCMYKScan(m *image.CMYK) {
+	w, h := m.Rect.Dx(), m.Rect.Dy()
+	var block [64]float64
+	var dc [4]int
+	for my := 0; my < h; my += 8 {
+		for mx := 0; mx < w; mx += 8 {
+			for c := 0; c < 4; c++ {
+				for j := 0; j < 8; j++ {
+					row := m.Pix[(min(my+j, h-1))*m.Stride:]
+					for i := 0; i < 8; i++ {
+						block[j*8+i] = float64(255-row[min(mx+i, w-1)*4+c]) - 128
+					}
+				}
+				dc[c] = e.writeBlock(&block, 0, dc[c])
+			}
+		}
+	}
+}
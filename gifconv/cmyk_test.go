@@ -0,0 +1,102 @@
+package gifconv
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestRGBToCMYK(t *testing.T) {
+	tests := []struct {
+		name string
+		in   color.Color
+		want color.CMYK
+	}{
+		{"white", color.White, color.CMYK{0, 0, 0, 0}},
+		{"black", color.Black, color.CMYK{0, 0, 0, 255}},
+		{"red", color.RGBA{255, 0, 0, 255}, color.CMYK{0, 255, 255, 0}},
+		{"transparent", color.Transparent, color.CMYK{0, 0, 0, 0}}, // 纸张白色
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(3, 4, 5, 6))
+			for i := 0; i < len(src.Pix); i += 4 {
+				r, g, b, a := tt.in.RGBA()
+				src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = uint8(r>>8), uint8(g>>8), uint8(b>>8), uint8(a>>8)
+			}
+			dst := RGBToCMYK(src)
+			if got, want := dst.Bounds(), image.Rect(0, 0, 2, 2); got != want {
+				t.Fatalf("bounds = %v, want %v", got, want)
+			}
+			if got := dst.CMYKAt(1, 1); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeJPEGCMYKRoundTrip(t *testing.T) {
+	// 纯色图像经 image/jpeg 解码后应得到 *image.CMYK 且颜色一致；
+	// Adobe APP14 的反相处理有误时各通道会变为 255 - v
+	tests := []struct {
+		name string
+		in   color.RGBA
+		want color.CMYK
+	}{
+		{"white", color.RGBA{255, 255, 255, 255}, color.CMYK{0, 0, 0, 0}},
+		{"black", color.RGBA{0, 0, 0, 255}, color.CMYK{0, 0, 0, 255}},
+		{"red", color.RGBA{255, 0, 0, 255}, color.CMYK{0, 255, 255, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewRGBA(image.Rect(0, 0, 20, 12))
+			for i := 0; i < len(src.Pix); i += 4 {
+				src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = tt.in.R, tt.in.G, tt.in.B, tt.in.A
+			}
+			var buf bytes.Buffer
+			if err := EncodeJPEGCMYK(&buf, src, 100); err != nil {
+				t.Fatalf("EncodeJPEGCMYK: %v", err)
+			}
+			data := buf.Bytes()
+
+			// SOI 之后紧接 Adobe APP14，transform 为 0
+			app14 := []byte{0xff, 0xee, 0, 14, 'A', 'd', 'o', 'b', 'e', 0, 100}
+			if !bytes.HasPrefix(data[2:], app14) || data[17] != 0 {
+				t.Fatalf("missing Adobe APP14 with transform 0: % x", data[:18])
+			}
+
+			m, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("jpeg.Decode: %v", err)
+			}
+			cmyk, ok := m.(*image.CMYK)
+			if !ok {
+				t.Fatalf("decoded %T, want *image.CMYK", m)
+			}
+			if got, want := cmyk.Bounds(), src.Bounds(); got != want {
+				t.Errorf("bounds = %v, want %v", got, want)
+			}
+			for y := 0; y < 12; y++ {
+				for x := 0; x < 20; x++ {
+					if got := cmyk.CMYKAt(x, y); !nearCMYK(got, tt.want, 2) {
+						t.Fatalf("at (%d,%d): got %v, want %v", x, y, got, tt.want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// 判断两个 CMYK 颜色的各通道之差是否都不超过 tolerance
+func nearCMYK(a, b color.CMYK, tolerance int) bool {
+	for _, d := range []int{
+		int(a.C) - int(b.C), int(a.M) - int(b.M), int(a.Y) - int(b.Y), int(a.K) - int(b.K),
+	} {
+		if d < -tolerance || d > tolerance {
+			return false
+		}
+	}
+	return true
+}
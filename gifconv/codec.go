@@ -0,0 +1,95 @@
+package gifconv
+
+import (
+	"errors"
+	"image"
+	"image/gif"
+	"image/png"
+	"io"
+
+	"github.com/chai2010/webp"
+	"golang.org/x/image/bmp"
+)
+
+// EncodeOptions 单帧编码的格式与参数，零值表示 PNG 默认压缩
+type EncodeOptions struct {
+	Format  Format
+	Quality int // JPEG、有损 WebP 与有损 AVIF 的质量（1-100），为 0 时使用 90
+	// 仅对 WebP 与 AVIF 有效：使用无损编码
+	Lossless bool
+	// 仅对 JPEG 有效：色度子采样，以及转换为 CMYK 编码
+	JPEGSubsampling JPEGSubsampling
+	JPEGCMYK        bool
+	// 仅对 PNG 有效：压缩级别，不超过 256 色时写为调色板 PNG，以及写为 Adam7 交错 PNG
+	PNGCompression png.CompressionLevel
+	Paletted       bool
+	Interlaced     bool
+	// 仅对 PNG 有效：大于 0 时以中位切分法减少到该颜色数并写为调色板 PNG，可选择抖动
+	Colors int
+	Dither bool
+	// 仅对 TIFF 有效：压缩方式
+	TIFFCompression TIFFCompression
+	// 仅对 ICO 有效：包含的图标边长，为空时使用 DefaultICOSizes
+	ICOSizes []int
+}
+
+// DecodeGIF 从 r 读取并解码完整的 GIF 动画，数据无效时返回 *DecodeError，不包含任何帧时返回 ErrNoFrames
+func DecodeGIF(r io.Reader) (*gif.GIF, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, &DecodeError{Frame: -1, Err: err}
+	}
+	if err := checkGIF(g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// EncodeFrame 按 opts 将单帧编码写入 w，失败时返回 *EncodeError
+func EncodeFrame(w io.Writer, img image.Image, opts EncodeOptions) error {
+	err := encodeFrame(w, img, opts)
+	var encErr *EncodeError
+	if err != nil && !errors.As(err, &encErr) {
+		err = &EncodeError{Frame: -1, Err: err}
+	}
+	return err
+}
+
+func encodeFrame(w io.Writer, img image.Image, opts EncodeOptions) error {
+	quality := opts.Quality
+	if quality == 0 {
+		quality = 90
+	}
+	switch opts.Format {
+	case FormatJPEG:
+		if opts.JPEGCMYK {
+			return EncodeJPEGCMYK(w, img, quality)
+		}
+		return EncodeJPEG(w, img, quality, opts.JPEGSubsampling)
+	case FormatWebP:
+		return webp.Encode(w, img, &webp.Options{Lossless: opts.Lossless, Quality: float32(quality)})
+	case FormatAVIF:
+		return encodeAVIF(w, img, quality, opts.Lossless)
+	case FormatBMP:
+		// 含透明像素时写出 32 位 BMP，否则为 24 位
+		return bmp.Encode(w, img)
+	case FormatTIFF:
+		return EncodeTIFF(w, []image.Image{img}, opts.TIFFCompression)
+	case FormatICO:
+		return EncodeICO(w, img, opts.ICOSizes)
+	default:
+		// 不超过 256 色时写出调色板 PNG，否则仍为真彩色
+		if opts.Colors > 0 {
+			img = QuantizeColors(img, opts.Colors, opts.Dither)
+		} else if opts.Paletted {
+			if p, ok := Palettize(img); ok {
+				img = p
+			}
+		}
+		if opts.Interlaced {
+			return EncodeInterlacedPNG(w, img, opts.PNGCompression)
+		}
+		enc := &png.Encoder{CompressionLevel: opts.PNGCompression}
+		return enc.Encode(w, img)
+	}
+}
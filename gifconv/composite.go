@@ -0,0 +1,170 @@
+package gifconv
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// GIF disposal methods
+const (
+	disposalNone       = 0x01
+	disposalBackground = 0x02
+	disposalPrevious   = 0x03
+)
+
+// DisposalName 返回处置方法的可读名称
+func DisposalName(disposal byte) string {
+	switch disposal {
+	case 0:
+		return "unspecified"
+	case disposalNone:
+		return "none"
+	case disposalBackground:
+		return "background"
+	case disposalPrevious:
+		return "previous"
+	default:
+		return fmt.Sprintf("unknown(%d)", disposal)
+	}
+}
+
+// PlayCount 将 image/gif 的 LoopCount 转换为总播放次数，0 表示无限循环。
+// LoopCount 为 0 表示无限循环，-1 表示只播放一次，n 表示首次播放后再循环 n 次。
+func PlayCount(loopCount int) int {
+	switch {
+	case loopCount == 0:
+		return 0
+	case loopCount < 0:
+		return 1
+	default:
+		return loopCount + 1
+	}
+}
+
+// CanvasBounds 计算画布范围：优先使用逻辑屏幕尺寸，否则取所有帧范围的并集
+func CanvasBounds(g *gif.GIF) image.Rectangle {
+	if g.Config.Width > 0 && g.Config.Height > 0 {
+		return image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	}
+	var bounds image.Rectangle
+	for _, frame := range g.Image {
+		bounds = bounds.Union(frame.Bounds())
+	}
+	return bounds
+}
+
+// Compositor 按顺序将 GIF 帧叠加到共享画布上，总工作量与帧数成线性关系。
+// 帧的处置方法在该帧显示之后、下一帧绘制之前生效
+type Compositor struct {
+	gif     *gif.GIF
+	decoder *FrameDecoder
+	canvas  *image.RGBA
+	next    int
+
+	// 上一帧的处置方法与范围，以及 disposalPrevious 需要恢复的画布
+	disposal byte
+	bounds   image.Rectangle
+	previous *image.RGBA
+}
+
+// NewCompositor 创建一个从第 0 帧开始叠加的 Compositor
+func NewCompositor(g *gif.GIF) *Compositor {
+	return &Compositor{
+		gif:    g,
+		canvas: image.NewRGBA(CanvasBounds(g)),
+	}
+}
+
+// NewStreamingCompositor 创建一个逐帧从 d 解码像素的 Compositor，g 为 NewFrameDecoder 返回的帧信息
+func NewStreamingCompositor(g *gif.GIF, d *FrameDecoder) *Compositor {
+	c := NewCompositor(g)
+	c.decoder = d
+	return c
+}
+
+// Advance 处置上一帧后将下一帧叠加到画布上并返回该帧序号，所有帧处理完毕后返回 io.EOF
+func (c *Compositor) Advance() (int, error) {
+	if c.next >= len(c.gif.Image) {
+		return c.next, io.EOF
+	}
+	i := c.next
+	frame := c.gif.Image[i]
+	if c.decoder != nil {
+		var err error
+		if frame, err = c.decoder.Frame(i); err != nil {
+			return i, err
+		}
+	}
+	c.dispose()
+
+	c.disposal = 0
+	if i < len(c.gif.Disposal) {
+		c.disposal = c.gif.Disposal[i]
+	}
+	c.bounds = frame.Bounds()
+	if c.disposal == disposalPrevious {
+		// 保存绘制本帧之前的画布，供本帧显示后恢复
+		c.previous = cloneRGBA(c.canvas)
+	}
+	drawFrame(c.canvas, frame)
+	c.next++
+	return i, nil
+}
+
+// 按上一帧的处置方法处理画布：background 将该帧区域清为透明，
+// previous 将该帧区域恢复为绘制该帧之前的内容，其余方法保留画布
+func (c *Compositor) dispose() {
+	switch c.disposal {
+	case disposalBackground:
+		draw.Draw(c.canvas, c.bounds, image.Transparent, image.Point{}, draw.Src)
+	case disposalPrevious:
+		draw.Draw(c.canvas, c.bounds, c.previous, c.bounds.Min, draw.Src)
+		c.previous = nil
+	}
+}
+
+// Canvas 返回当前画布，其内容会在下一次 Advance 时改变
+func (c *Compositor) Canvas() *image.RGBA {
+	return c.canvas
+}
+
+// Snapshot 返回当前画布的副本
+func (c *Compositor) Snapshot() *image.RGBA {
+	return cloneRGBA(c.canvas)
+}
+
+// Frames 返回所有帧按处置方法叠加后的完整图像，顺序与 GIF 中的帧一致。
+// 各帧在同一画布上增量叠加，总工作量与帧数成线性关系；返回的每个图像都是独立的副本
+func Frames(g *gif.GIF) ([]*image.RGBA, error) {
+	if err := checkGIF(g); err != nil {
+		return nil, err
+	}
+	frames := make([]*image.RGBA, 0, len(g.Image))
+	comp := NewCompositor(g)
+	for {
+		_, err := comp.Advance()
+		if err == io.EOF {
+			return frames, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, comp.Snapshot())
+	}
+}
+
+// 复制画布的当前状态
+func cloneRGBA(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	return dst
+}
+
+// 按帧自身带偏移的范围将其叠加到画布上，透明索引处保留画布原有像素
+func drawFrame(dst *image.RGBA, src *image.Paletted) {
+	bounds := src.Bounds()
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Over)
+}
@@ -0,0 +1,295 @@
+package gifconv
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"testing"
+)
+
+var (
+	transparent = color.RGBA{}
+	red         = color.RGBA{0xff, 0, 0, 0xff}
+	green       = color.RGBA{0, 0xff, 0, 0xff}
+	blue        = color.RGBA{0, 0, 0xff, 0xff}
+)
+
+// 以 palette 与像素索引构造位于 r 的帧
+func paletted(r image.Rectangle, palette color.Palette, indices ...uint8) *image.Paletted {
+	m := image.NewPaletted(r, palette)
+	copy(m.Pix, indices)
+	return m
+}
+
+// 将颜色按行优先顺序展开为 image.RGBA 的 Pix
+func rgbaPix(colors ...color.RGBA) []byte {
+	pix := make([]byte, 0, 4*len(colors))
+	for _, c := range colors {
+		pix = append(pix, c.R, c.G, c.B, c.A)
+	}
+	return pix
+}
+
+// 编码为 GIF 数据，使透明索引与调色板经过 image/gif 的真实编解码
+func encodeGIF(t *testing.T, g *gif.GIF) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("EncodeAll: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// 依次叠加所有帧，返回每帧之后画布的 Pix
+func compositeAll(t *testing.T, c *Compositor) [][]byte {
+	t.Helper()
+	var canvases [][]byte
+	for {
+		_, err := c.Advance()
+		if err == io.EOF {
+			return canvases
+		}
+		if err != nil {
+			t.Fatalf("Advance: %v", err)
+		}
+		canvases = append(canvases, c.Snapshot().Pix)
+	}
+}
+
+func TestCompositeTransparentIndex(t *testing.T) {
+	// 第 1 帧中透明索引处的像素应保留第 0 帧的内容
+	palette := color.Palette{red, green, blue, transparent}
+	canvas := image.Rect(0, 0, 3, 2)
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			paletted(canvas, palette, 0, 1, 0, 1, 0, 1),
+			paletted(canvas, palette, 3, 2, 3, 2, 3, 3),
+		},
+		Delay:    []int{0, 0},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{ColorModel: palette, Width: 3, Height: 2},
+	}
+	decoded, err := DecodeGIF(bytes.NewReader(encodeGIF(t, g)))
+	if err != nil {
+		t.Fatalf("DecodeGIF: %v", err)
+	}
+
+	want := [][]byte{
+		rgbaPix(red, green, red, green, red, green),
+		rgbaPix(red, blue, red, blue, red, green),
+	}
+	got := compositeAll(t, NewCompositor(decoded))
+	if len(got) != len(want) {
+		t.Fatalf("got %d frames, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("frame %d:\ngot  %v\nwant %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCompositeLocalPalettes(t *testing.T) {
+	// 前两帧各有局部调色板且透明索引不同，第 2 帧使用全局调色板；
+	// 若前一帧的局部调色板沿用到下一帧，第 2 帧的索引 0 会变为透明或蓝色
+	global := color.Palette{green, blue}
+	canvas := image.Rect(0, 0, 2, 1)
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			paletted(canvas, color.Palette{red, transparent}, 0, 1),
+			paletted(canvas, color.Palette{transparent, blue}, 0, 1),
+			paletted(canvas, global, 0, 0),
+		},
+		Delay:    []int{0, 0, 0},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone, gif.DisposalNone},
+		Config:   image.Config{ColorModel: global, Width: 2, Height: 1},
+	}
+	data := encodeGIF(t, g)
+	want := [][]byte{
+		rgbaPix(red, transparent),
+		rgbaPix(red, blue),
+		rgbaPix(green, green),
+	}
+
+	decoded, err := DecodeGIF(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeGIF: %v", err)
+	}
+	d, streamed, err := NewFrameDecoder(data)
+	if err != nil {
+		t.Fatalf("NewFrameDecoder: %v", err)
+	}
+	compositors := map[string]*Compositor{
+		"NewCompositor":          NewCompositor(decoded),
+		"NewStreamingCompositor": NewStreamingCompositor(streamed, d),
+	}
+	for name, c := range compositors {
+		got := compositeAll(t, c)
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %d frames, want %d", name, len(got), len(want))
+		}
+		for i := range want {
+			if !bytes.Equal(got[i], want[i]) {
+				t.Errorf("%s frame %d:\ngot  %v\nwant %v", name, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestCompositeOffsetFrame(t *testing.T) {
+	// 8x8 画布上位于 (2,3) 的 3x3 子帧只能改变自身范围内的像素，
+	// 其处置方法也只作用于该范围，不会被移到画布左上角
+	palette := color.Palette{green, red, blue}
+	full := image.Rect(0, 0, 8, 8)
+	sub := image.Rect(2, 3, 5, 6)
+	corner := image.Rect(7, 7, 8, 8)
+	tests := []struct {
+		disposal byte
+		inside   color.RGBA // 第 2 帧之后子帧范围内的颜色
+	}{
+		{gif.DisposalNone, red},
+		{gif.DisposalBackground, transparent},
+		{gif.DisposalPrevious, green},
+	}
+	for _, tt := range tests {
+		t.Run(DisposalName(tt.disposal), func(t *testing.T) {
+			g := &gif.GIF{
+				Image: []*image.Paletted{
+					image.NewPaletted(full, palette),
+					paletted(sub, palette, 1, 1, 1, 1, 1, 1, 1, 1, 1),
+					paletted(corner, palette, 2),
+				},
+				Delay:    []int{0, 0, 0},
+				Disposal: []byte{gif.DisposalNone, tt.disposal, gif.DisposalNone},
+				Config:   image.Config{ColorModel: palette, Width: 8, Height: 8},
+			}
+			decoded, err := DecodeGIF(bytes.NewReader(encodeGIF(t, g)))
+			if err != nil {
+				t.Fatalf("DecodeGIF: %v", err)
+			}
+			c := NewCompositor(decoded)
+			check := func(frame int, colorAt func(p image.Point) color.RGBA) {
+				t.Helper()
+				if _, err := c.Advance(); err != nil {
+					t.Fatalf("Advance: %v", err)
+				}
+				for y := 0; y < 8; y++ {
+					for x := 0; x < 8; x++ {
+						p := image.Pt(x, y)
+						if got, want := c.Canvas().RGBAAt(x, y), colorAt(p); got != want {
+							t.Errorf("frame %d at %v: got %v, want %v", frame, p, got, want)
+						}
+					}
+				}
+			}
+
+			check(0, func(image.Point) color.RGBA { return green })
+			check(1, func(p image.Point) color.RGBA {
+				if p.In(sub) {
+					return red
+				}
+				return green
+			})
+			check(2, func(p image.Point) color.RGBA {
+				switch {
+				case p.In(sub):
+					return tt.inside
+				case p.In(corner):
+					return blue
+				}
+				return green
+			})
+		})
+	}
+}
+
+func TestCompositeDisposal(t *testing.T) {
+	// 2x1 画布，调色板索引 0 为透明；frames 为各帧的范围、索引与处置方法，
+	// want 为每帧叠加之后的画布
+	palette := color.Palette{transparent, red, green, blue}
+	type frame struct {
+		bounds   image.Rectangle
+		disposal byte
+		indices  []uint8
+	}
+	left, right, both := image.Rect(0, 0, 1, 1), image.Rect(1, 0, 2, 1), image.Rect(0, 0, 2, 1)
+	tests := []struct {
+		name   string
+		frames []frame
+		want   [][]color.RGBA
+	}{
+		{
+			name: "none",
+			frames: []frame{
+				{both, gif.DisposalNone, []uint8{1, 2}},
+				{right, gif.DisposalNone, []uint8{3}},
+				{left, gif.DisposalNone, []uint8{0}},
+			},
+			want: [][]color.RGBA{{red, green}, {red, blue}, {red, blue}},
+		},
+		{
+			name: "unspecified",
+			frames: []frame{
+				{both, 0, []uint8{1, 2}},
+				{right, 0, []uint8{3}},
+			},
+			want: [][]color.RGBA{{red, green}, {red, blue}},
+		},
+		{
+			name: "background",
+			frames: []frame{
+				{both, gif.DisposalNone, []uint8{1, 2}},
+				{right, gif.DisposalBackground, []uint8{3}},
+				{left, gif.DisposalNone, []uint8{2}},
+			},
+			want: [][]color.RGBA{{red, green}, {red, blue}, {green, transparent}},
+		},
+		{
+			name: "previous",
+			frames: []frame{
+				{both, gif.DisposalNone, []uint8{1, 2}},
+				{right, gif.DisposalPrevious, []uint8{3}},
+				{left, gif.DisposalNone, []uint8{3}},
+			},
+			want: [][]color.RGBA{{red, green}, {red, blue}, {blue, green}},
+		},
+		{
+			name: "previous after background",
+			frames: []frame{
+				{both, gif.DisposalBackground, []uint8{1, 2}},
+				{left, gif.DisposalPrevious, []uint8{3}},
+				{right, gif.DisposalNone, []uint8{1}},
+			},
+			want: [][]color.RGBA{{red, green}, {blue, transparent}, {transparent, red}},
+		},
+		{
+			name: "previous on frame 0",
+			frames: []frame{
+				{both, gif.DisposalPrevious, []uint8{1, 2}},
+				{right, gif.DisposalNone, []uint8{3}},
+			},
+			want: [][]color.RGBA{{red, green}, {transparent, blue}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &gif.GIF{Config: image.Config{ColorModel: palette, Width: 2, Height: 1}}
+			for _, f := range tt.frames {
+				g.Image = append(g.Image, paletted(f.bounds, palette, f.indices...))
+				g.Delay = append(g.Delay, 0)
+				g.Disposal = append(g.Disposal, f.disposal)
+			}
+			got := compositeAll(t, NewCompositor(g))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d frames, want %d", len(got), len(tt.want))
+			}
+			for i, w := range tt.want {
+				if want := rgbaPix(w...); !bytes.Equal(got[i], want) {
+					t.Errorf("frame %d:\ngot  %v\nwant %v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Package gifconv 将 GIF 动画的各帧按照处置方法叠加为完整图像，
+// 供命令行工具或其他 Go 程序复用。
+package gifconv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ErrNoFrames 表示 GIF 中不包含任何帧
+var ErrNoFrames = errors.New("gifconv: GIF has no frames")
+
+// Converter 将 GIF 动画转换为逐帧的完整图像，并按选项处理与编码各帧
+type Converter struct {
+	encode        EncodeOptions
+	width, height int
+	filter        xdraw.Interpolator
+	background    color.Color
+	workers       int
+	progress      chan<- Progress
+
+	// 背景图像及按帧尺寸缓存的背景图层，各协程并发处理帧时共享
+	backgroundImage image.Image
+	backgroundFit   BackgroundFit
+	layerMu         sync.Mutex
+	layers          map[image.Point]*image.RGBA
+}
+
+// NewConverter 创建一个 Converter，未指定的选项使用默认值
+func NewConverter(opts ...ConverterOption) *Converter {
+	c := &Converter{
+		encode:  EncodeOptions{Quality: 90},
+		filter:  xdraw.CatmullRom,
+		workers: runtime.NumCPU(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.filter == nil {
+		c.filter = xdraw.CatmullRom
+	}
+	return c
+}
+
+// Process 对叠加后的帧应用背景与缩放选项，未设置时原样返回。
+// 设置了背景图像时先绘制背景色（如有）与背景图像，再将帧叠加在上面
+func (c *Converter) Process(img image.Image) image.Image {
+	if c.backgroundImage != nil {
+		img = FlattenOnto(img, c.backgroundLayer(img.Bounds().Size()))
+	} else if c.background != nil {
+		img = Flatten(img, c.background)
+	}
+	if c.width > 0 || c.height > 0 {
+		img = Resize(img, c.width, c.height, c.filter)
+	}
+	return img
+}
+
+// 返回与帧尺寸相同的背景图层，同一尺寸只生成一次
+func (c *Converter) backgroundLayer(size image.Point) *image.RGBA {
+	c.layerMu.Lock()
+	defer c.layerMu.Unlock()
+	layer, ok := c.layers[size]
+	if !ok {
+		if c.layers == nil {
+			c.layers = make(map[image.Point]*image.RGBA)
+		}
+		layer = BackgroundLayer(c.backgroundImage, size, c.backgroundFit, c.background)
+		c.layers[size] = layer
+	}
+	return layer
+}
+
+// Encode 按选项中的格式将单帧编码写入 w
+func (c *Converter) Encode(w io.Writer, img image.Image) error {
+	return EncodeFrame(w, img, c.encode)
+}
+
+// Progress ConvertAll 每处理完一帧时发送的进度：Frame 为该帧的序号，Done 为已完成的帧数
+type Progress struct {
+	Frame int
+	Done  int
+	Total int
+}
+
+// ConvertAll 返回所有帧叠加并经 Process 处理后的图像，顺序与 GIF 中的帧一致。
+// 叠加按顺序进行，处理由多个协程并行完成；ctx 取消时在当前帧完成后停止并返回 ctx.Err()。
+// 设置了 WithProgress 时每处理完一帧发送一次进度，返回前不会关闭通道
+func (c *Converter) ConvertAll(ctx context.Context, g *gif.GIF) ([]image.Image, error) {
+	if err := checkGIF(g); err != nil {
+		return nil, err
+	}
+
+	frames := make([]image.Image, 0, len(g.Image))
+	comp := NewCompositor(g)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		_, err := comp.Advance()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, comp.Snapshot())
+	}
+
+	// 按协程数分段并行处理
+	var wg sync.WaitGroup
+	var done atomic.Int64
+	for w := 0; w < c.workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(frames); i += c.workers {
+				frames[i] = c.Process(frames[i])
+				c.sendProgress(Progress{Frame: i, Done: int(done.Add(1)), Total: len(frames)})
+			}
+		}(w)
+	}
+	wg.Wait()
+	return frames, nil
+}
+
+// 非阻塞地发送进度，未设置通道或通道已满时丢弃，接收方处理缓慢不会拖慢转换
+func (c *Converter) sendProgress(p Progress) {
+	if c.progress == nil {
+		return
+	}
+	select {
+	case c.progress <- p:
+	default:
+	}
+}
+
+// ConvertFrame 返回第 i 帧叠加后的完整图像，之前的帧会依次参与叠加
+func (c *Converter) ConvertFrame(ctx context.Context, g *gif.GIF, i int) (image.Image, error) {
+	if err := checkGIF(g); err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(g.Image) {
+		return nil, fmt.Errorf("gifconv: frame index %d out of range [0, %d)", i, len(g.Image))
+	}
+
+	comp := NewCompositor(g)
+	for j := 0; j <= i; j++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := comp.Advance(); err != nil {
+			return nil, err
+		}
+	}
+	return c.Process(comp.Snapshot()), nil
+}
+
+func checkGIF(g *gif.GIF) error {
+	if g == nil || len(g.Image) == 0 {
+		return ErrNoFrames
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package gifconv
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+// ParseCropRect 解析 "x,y,w,h" 形式的裁剪区域
+func ParseCropRect(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("gifconv: crop %q must be x,y,w,h", s)
+	}
+	var v [4]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("gifconv: crop %q must be x,y,w,h", s)
+		}
+		v[i] = n
+	}
+	if v[2] <= 0 || v[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("gifconv: crop %q must have positive width and height", s)
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
+
+// ClampCrop 将裁剪区域限制在图像范围内，完全落在范围外时返回错误
+func ClampCrop(r, bounds image.Rectangle) (image.Rectangle, error) {
+	clamped := r.Intersect(bounds)
+	if clamped.Empty() {
+		return image.Rectangle{}, fmt.Errorf("gifconv: crop %v lies outside the image %v", r, bounds)
+	}
+	return clamped, nil
+}
+
+// Crop 复制图像中的 r 区域，返回从原点开始的新图像，r 需已限制在图像范围内
+func Crop(src image.Image, r image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, r.Min, draw.Src)
+	return dst
+}
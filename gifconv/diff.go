@@ -0,0 +1,30 @@
+package gifconv
+
+import "image"
+
+// DiffCleared 标记由不透明变为透明的像素（通常来自 background 处置）的颜色
+var DiffCleared = [4]uint8{0xff, 0x00, 0xff, 0xff}
+
+// Diff 返回两张同尺寸完整帧之间的差异图像：未变化的像素为透明，
+// 变化的像素取 cur 中的颜色，在 cur 中变为透明的像素以 DiffCleared 标出
+func Diff(prev, cur *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(cur.Rect)
+	for y := cur.Rect.Min.Y; y < cur.Rect.Max.Y; y++ {
+		for x := cur.Rect.Min.X; x < cur.Rect.Max.X; x++ {
+			c := cur.Pix[cur.PixOffset(x, y):][:4]
+			d := dst.Pix[dst.PixOffset(x, y):][:4]
+			if (image.Point{x, y}).In(prev.Rect) {
+				p := prev.Pix[prev.PixOffset(x, y):][:4]
+				if p[0] == c[0] && p[1] == c[1] && p[2] == c[2] && p[3] == c[3] {
+					continue
+				}
+			}
+			if c[3] == 0 {
+				copy(d, DiffCleared[:])
+			} else {
+				copy(d, c)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,42 @@
+package gifconv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DecodeError 表示 GIF 数据无法解码，Frame 为出错的帧序号，文件结构本身有误时为 -1。
+// 调用方可以用 errors.As 取得帧序号，用 errors.Is 检查 Err 中的底层错误
+type DecodeError struct {
+	Frame int
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Frame < 0 {
+		return "gifconv: decoding GIF: " + causeText(e.Err)
+	}
+	return fmt.Sprintf("gifconv: decoding frame %d: %s", e.Frame, causeText(e.Err))
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// EncodeError 表示图像无法编码，Frame 为多帧输出中出错的帧序号，编码单张图像时为 -1
+type EncodeError struct {
+	Frame int
+	Err   error
+}
+
+func (e *EncodeError) Error() string {
+	if e.Frame < 0 {
+		return "gifconv: encoding image: " + causeText(e.Err)
+	}
+	return fmt.Sprintf("gifconv: encoding frame %d: %s", e.Frame, causeText(e.Err))
+}
+
+func (e *EncodeError) Unwrap() error { return e.Err }
+
+// 本包的错误信息都以 "gifconv: " 开头，包装后去掉重复的前缀
+func causeText(err error) string {
+	return strings.TrimPrefix(err.Error(), "gifconv: ")
+}
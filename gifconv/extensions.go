@@ -0,0 +1,67 @@
+package gifconv
+
+import "bytes"
+
+// Extension GIF 中的注释扩展或应用扩展块，image/gif 解码时会丢弃这些数据。
+// Frame 为其后第一帧的序号，位于所有帧之后时等于帧数
+type Extension struct {
+	Frame   int
+	Comment bool   // 注释扩展，否则为应用扩展
+	AppID   string // 应用扩展的标识与认证码，如 "NETSCAPE2.0"、"XMP DataXMP"
+	// 注释文本或应用数据：各子块的内容依次拼接，XMP 为去掉魔术尾部后的原始数据包
+	Data []byte
+}
+
+// XMP 应用扩展的标识
+const xmpAppID = "XMP DataXMP"
+
+// ReadExtensions 扫描 GIF 数据，按出现顺序返回其中的注释扩展与应用扩展，不解码像素
+func ReadExtensions(data []byte) ([]Extension, error) {
+	d, _, err := NewFrameDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.Extensions(), nil
+}
+
+// Extensions 返回 GIF 中的注释扩展与应用扩展
+func (d *FrameDecoder) Extensions() []Extension {
+	return d.extensions
+}
+
+// 解析一个完整的扩展块（含引入符与结束块），不是注释或应用扩展时返回 false
+func parseExtension(block []byte, frame int) (Extension, bool) {
+	switch block[1] {
+	case extComment:
+		return Extension{Frame: frame, Comment: true, Data: joinSubBlocks(block[2:])}, true
+	case extApplication:
+		// 应用扩展的第一个子块固定为 11 字节的标识与认证码
+		if len(block) < 14 || block[2] != 11 {
+			return Extension{}, false
+		}
+		ext := Extension{Frame: frame, AppID: string(block[3:14])}
+		if ext.AppID == xmpAppID {
+			// XMP 数据包直接写在标识之后，子块长度字节就是数据本身，
+			// 末尾是 257 字节的魔术尾部（0x01、0xff 递减到 0x00）与结束块
+			raw := block[14:]
+			if n := len(raw) - 258; n >= 0 && raw[n] == 0x01 && raw[n+1] == 0xff && raw[n+256] == 0x00 {
+				ext.Data = bytes.Clone(raw[:n])
+				return ext, true
+			}
+		}
+		ext.Data = joinSubBlocks(block[14:])
+		return ext, true
+	}
+	return Extension{}, false
+}
+
+// 依次拼接子块序列的内容，遇到结束块或数据结尾时停止
+func joinSubBlocks(data []byte) []byte {
+	var out []byte
+	for len(data) > 0 && data[0] != 0 {
+		n := min(int(data[0]), len(data)-1)
+		out = append(out, data[1:1+n]...)
+		data = data[1+n:]
+	}
+	return out
+}
@@ -0,0 +1,26 @@
+package gifconv
+
+import "image"
+
+// Flip 水平和/或垂直镜像图像，返回从原点开始的新图像
+func Flip(src image.Image, horizontal, vertical bool) *image.RGBA {
+	in := toRGBA(src)
+	w, h := in.Rect.Dx(), in.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		dy := y
+		if vertical {
+			dy = h - 1 - y
+		}
+		for x := 0; x < w; x++ {
+			dx := x
+			if horizontal {
+				dx = w - 1 - x
+			}
+			si := y*in.Stride + x*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], in.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,18 @@
+package gifconv
+
+import "image"
+
+// Grayscale 按 0.299/0.587/0.114 的亮度权重将图像转换为 8 位灰度图像，透明通道被丢弃
+func Grayscale(src image.Image) *image.Gray {
+	in := toRGBA(src)
+	w, h := in.Rect.Dx(), in.Rect.Dy()
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := in.Pix[y*in.Stride+x*4:]
+			lum := 299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])
+			dst.Pix[y*dst.Stride+x] = uint8((lum + 500) / 1000)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,139 @@
+package gifconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DefaultICOSizes 未指定尺寸时 ICO 中包含的图标边长
+var DefaultICOSizes = []int{16, 32, 48}
+
+// ParseICOSizes 解析逗号分隔的图标边长（1-256），结果去重并升序排列
+func ParseICOSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, field := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 || n > 256 {
+			return nil, fmt.Errorf("gifconv: invalid icon size %q (want 1-256)", field)
+		}
+		if !slices.Contains(sizes, n) {
+			sizes = append(sizes, n)
+		}
+	}
+	slices.Sort(sizes)
+	return sizes, nil
+}
+
+// EncodeICO 将图像缩放为 sizes 中的各边长并写入同一个 ICO 文件，sizes 为空时使用 DefaultICOSizes。
+// 非正方形图像按比例缩放后居中放在透明画布上；256 像素的图标以 PNG 存储，
+// 其余为 32 位 BMP 加透明掩码，兼容只识别 BMP 图标的旧程序
+func EncodeICO(w io.Writer, img image.Image, sizes []int) error {
+	if len(sizes) == 0 {
+		sizes = DefaultICOSizes
+	}
+	if img.Bounds().Empty() {
+		return errors.New("gifconv: zero-size ICO image")
+	}
+
+	images := make([][]byte, len(sizes))
+	for i, size := range sizes {
+		if size < 1 || size > 256 {
+			return fmt.Errorf("gifconv: invalid icon size %d (want 1-256)", size)
+		}
+		icon := fitSquare(img, size)
+		if size == 256 {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, icon); err != nil {
+				return err
+			}
+			images[i] = buf.Bytes()
+		} else {
+			images[i] = encodeICOBitmap(icon)
+		}
+	}
+
+	// ICONDIR 与各 ICONDIRENTRY 之后依次存放图像数据
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, uint16(len(sizes))})
+	offset := 6 + 16*len(sizes)
+	for i, size := range sizes {
+		// 边长字段只有一个字节，0 表示 256
+		dim := uint8(size % 256)
+		buf.Write([]byte{dim, dim, 0, 0})
+		binary.Write(&buf, binary.LittleEndian, [2]uint16{1, 32})
+		binary.Write(&buf, binary.LittleEndian, [2]uint32{uint32(len(images[i])), uint32(offset)})
+		offset += len(images[i])
+	}
+	for _, data := range images {
+		buf.Write(data)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// 按比例缩放到边长为 size 的正方形内并居中，空白处保持透明
+func fitSquare(img image.Image, size int) *image.NRGBA {
+	src := img.Bounds().Size()
+	fit := image.Pt(size, max(1, (src.Y*size+src.X/2)/src.X))
+	if src.Y > src.X {
+		fit = image.Pt(max(1, (src.X*size+src.Y/2)/src.Y), size)
+	}
+	offset := image.Pt((size-fit.X)/2, (size-fit.Y)/2)
+	dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+	xdraw.CatmullRom.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(fit)}, img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// 将正方形图标编码为 ICO 中的 BMP 数据：不含文件头的 BITMAPINFOHEADER，
+// 高度字段为两倍边长，之后是自下而上的 BGRA 像素与 1 位 AND 掩码（1 表示透明）
+func encodeICOBitmap(img *image.NRGBA) []byte {
+	size := img.Bounds().Dx()
+	maskStride := (size + 31) / 32 * 4
+	pixelBytes := size * size * 4
+	maskBytes := maskStride * size
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, struct {
+		Size          uint32
+		Width, Height int32
+		Planes, Bits  uint16
+		Compression   uint32
+		ImageSize     uint32
+		XPPM, YPPM    int32
+		Used, Import  uint32
+	}{
+		Size:      40,
+		Width:     int32(size),
+		Height:    int32(size * 2),
+		Planes:    1,
+		Bits:      32,
+		ImageSize: uint32(pixelBytes + maskBytes),
+	})
+
+	pixels := make([]byte, 0, pixelBytes)
+	mask := make([]byte, maskBytes)
+	for y := size - 1; y >= 0; y-- {
+		row := size - 1 - y
+		for x := 0; x < size; x++ {
+			c := img.NRGBAAt(x, y)
+			pixels = append(pixels, c.B, c.G, c.R, c.A)
+			if c.A == 0 {
+				mask[row*maskStride+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	buf.Write(pixels)
+	buf.Write(mask)
+	return buf.Bytes()
+}
@@ -0,0 +1,253 @@
+package gifconv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// Adam7 交错的七遍扫描：每遍的起始列、起始行与列、行间隔
+var adam7Passes = [7]struct{ x, y, dx, dy int }{
+	{0, 0, 8, 8},
+	{4, 0, 8, 8},
+	{0, 4, 4, 8},
+	{2, 0, 4, 4},
+	{0, 2, 2, 4},
+	{1, 0, 2, 2},
+	{0, 1, 1, 2},
+}
+
+// PNG 的颜色类型
+const (
+	pngGray      = 0
+	pngRGB       = 2
+	pngPaletted  = 3
+	pngRGBAlpha  = 6
+	pngInterlace = 1
+)
+
+// EncodeInterlacedPNG 将图像编码为 Adam7 交错的 PNG，浏览器可以在下载过程中逐步显示。
+// image/png 不支持交错，因此由本包写出：灰度图像为 8 位灰度，调色板图像保留调色板与透明度，
+// 其余图像完全不透明时为 RGB，否则为非预乘的 RGBA
+func EncodeInterlacedPNG(w io.Writer, m image.Image, level png.CompressionLevel) error {
+	b := m.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() >= 1<<31 || b.Dy() >= 1<<31 {
+		return errors.New("gifconv: invalid PNG image size")
+	}
+
+	// 将像素整理为每行连续的字节，bpp 为每像素字节数
+	var pix []byte
+	var colorType, bpp int
+	var palette color.Palette
+	switch src := m.(type) {
+	case *image.Gray:
+		colorType, bpp = pngGray, 1
+		pix = make([]byte, 0, b.Dx()*b.Dy())
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pix = append(pix, src.Pix[src.PixOffset(b.Min.X, y):][:b.Dx()]...)
+		}
+	case *image.Paletted:
+		colorType, bpp, palette = pngPaletted, 1, src.Palette
+		pix = make([]byte, 0, b.Dx()*b.Dy())
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			pix = append(pix, src.Pix[src.PixOffset(b.Min.X, y):][:b.Dx()]...)
+		}
+	default:
+		nrgba := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(nrgba, nrgba.Bounds(), m, b.Min, draw.Src)
+		colorType, bpp, pix = pngRGBAlpha, 4, nrgba.Pix
+		if opaqueNRGBA(nrgba) {
+			colorType, bpp = pngRGB, 3
+			rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+			for i := 0; i < len(pix); i += 4 {
+				rgb = append(rgb, pix[i], pix[i+1], pix[i+2])
+			}
+			pix = rgb
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x89PNG\r\n\x1a\n")
+	var ihdr [13]byte
+	binary.BigEndian.PutUint32(ihdr[0:4], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:8], uint32(b.Dy()))
+	ihdr[8] = 8
+	ihdr[9] = byte(colorType)
+	ihdr[12] = pngInterlace
+	writePNGChunk(bw, "IHDR", ihdr[:])
+
+	if colorType == pngPaletted {
+		plte := make([]byte, 0, 3*len(palette))
+		trns := make([]byte, 0, len(palette))
+		lastAlpha := -1
+		for i, c := range palette {
+			n := color.NRGBAModel.Convert(c).(color.NRGBA)
+			plte = append(plte, n.R, n.G, n.B)
+			trns = append(trns, n.A)
+			if n.A != 0xff {
+				lastAlpha = i
+			}
+		}
+		writePNGChunk(bw, "PLTE", plte)
+		if lastAlpha >= 0 {
+			writePNGChunk(bw, "tRNS", trns[:lastAlpha+1])
+		}
+	}
+
+	data, err := interlacedPNGData(pix, b.Dx(), b.Dy(), bpp, colorType != pngPaletted, level)
+	if err != nil {
+		return err
+	}
+	writePNGChunk(bw, "IDAT", data)
+	writePNGChunk(bw, "IEND", nil)
+	return bw.Flush()
+}
+
+// 判断图像是否完全不透明
+func opaqueNRGBA(m *image.NRGBA) bool {
+	for i := 3; i < len(m.Pix); i += 4 {
+		if m.Pix[i] != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// 写出一个 PNG 数据块：长度、类型、数据与 CRC
+func writePNGChunk(w io.Writer, typ string, data []byte) {
+	var n [4]byte
+	binary.BigEndian.PutUint32(n[:], uint32(len(data)))
+	w.Write(n[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	io.WriteString(w, typ)
+	w.Write(data)
+	binary.BigEndian.PutUint32(n[:], crc.Sum32())
+	w.Write(n[:])
+}
+
+// 按 Adam7 的七遍扫描依次写出各遍的像素行并压缩。每行前为过滤类型，filter 为 false 时（调色板图像）
+// 不过滤，否则像 image/png 一样为每行选择绝对值之和最小的过滤方式；宽或高为 0 的扫描遍不写出任何行
+func interlacedPNGData(pix []byte, width, height, bpp int, filter bool, level png.CompressionLevel) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&buf, zlibLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	stride := width * bpp
+	for _, p := range adam7Passes {
+		pw := (width - p.x + p.dx - 1) / p.dx
+		ph := (height - p.y + p.dy - 1) / p.dy
+		if pw <= 0 || ph <= 0 {
+			continue
+		}
+		rowLen := pw * bpp
+		prev := make([]byte, rowLen)
+		cur := make([]byte, rowLen)
+		filtered := make([]byte, 1+rowLen)
+		for y := p.y; y < height; y += p.dy {
+			for n, x := 0, p.x; x < width; n, x = n+1, x+p.dx {
+				copy(cur[n*bpp:(n+1)*bpp], pix[y*stride+x*bpp:])
+			}
+			if filter {
+				filterPNGRow(filtered, cur, prev, bpp)
+			} else {
+				filtered[0] = 0
+				copy(filtered[1:], cur)
+			}
+			if _, err := zw.Write(filtered); err != nil {
+				return nil, err
+			}
+			prev, cur = cur, prev
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 依次尝试 None、Sub、Up、Average 与 Paeth 五种过滤，将结果最小的一种写入 dst（首字节为过滤类型）
+func filterPNGRow(dst, cur, prev []byte, bpp int) {
+	best := -1
+	candidate := make([]byte, len(cur))
+	for ft := 0; ft < 5; ft++ {
+		sum := 0
+		for i := range cur {
+			var a, c byte
+			if i >= bpp {
+				a, c = cur[i-bpp], prev[i-bpp]
+			}
+			up := prev[i]
+			var v byte
+			switch ft {
+			case 0:
+				v = cur[i]
+			case 1:
+				v = cur[i] - a
+			case 2:
+				v = cur[i] - up
+			case 3:
+				v = cur[i] - byte((int(a)+int(up))/2)
+			case 4:
+				v = cur[i] - paeth(a, up, c)
+			}
+			candidate[i] = v
+			sum += absInt8(v)
+		}
+		if best < 0 || sum < best {
+			best = sum
+			dst[0] = byte(ft)
+			copy(dst[1:], candidate)
+		}
+	}
+}
+
+// Paeth 预测：取 a（左）、b（上）、c（左上）中最接近 a+b-c 的一个
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// 将过滤后的字节视为有符号数时的绝对值
+func absInt8(v byte) int {
+	return abs(int(int8(v)))
+}
+
+// 将 png.CompressionLevel 对应到 zlib 的压缩级别
+func zlibLevel(level png.CompressionLevel) int {
+	switch level {
+	case png.NoCompression:
+		return zlib.NoCompression
+	case png.BestSpeed:
+		return zlib.BestSpeed
+	case png.BestCompression:
+		return zlib.BestCompression
+	default:
+		return zlib.DefaultCompression
+	}
+}
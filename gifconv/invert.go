@@ -0,0 +1,18 @@
+package gifconv
+
+import "image"
+
+// Invert 返回图像的负片：R、G、B 分量取 255 减原值，透明通道保持不变。
+// 像素为预乘 alpha，因此以 alpha 代替 255 计算，完全透明的像素仍为透明
+func Invert(src image.Image) *image.RGBA {
+	in := toRGBA(src)
+	dst := image.NewRGBA(in.Rect)
+	for i := 0; i+3 < len(in.Pix); i += 4 {
+		a := in.Pix[i+3]
+		dst.Pix[i+0] = a - in.Pix[i+0]
+		dst.Pix[i+1] = a - in.Pix[i+1]
+		dst.Pix[i+2] = a - in.Pix[i+2]
+		dst.Pix[i+3] = a
+	}
+	return dst
+}
@@ -0,0 +1,36 @@
+package gifconv
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestInvert(t *testing.T) {
+	// 纯色图像的负片：非预乘颜色取 255 减原值，alpha 保持不变
+	tests := []struct {
+		in, want color.NRGBA
+	}{
+		{color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 255, 255, 255}},
+		{color.NRGBA{10, 128, 200, 255}, color.NRGBA{245, 127, 55, 255}},
+		{color.NRGBA{255, 255, 255, 128}, color.NRGBA{0, 0, 0, 128}},
+		{color.NRGBA{0, 0, 0, 128}, color.NRGBA{255, 255, 255, 128}},
+		{color.NRGBA{0, 0, 0, 0}, color.NRGBA{0, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		src := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+		draw.Draw(src, src.Rect, image.NewUniform(tt.in), image.Point{}, draw.Src)
+		dst := Invert(src)
+		if dst.Rect != src.Rect {
+			t.Errorf("Invert(%v): bounds = %v, want %v", tt.in, dst.Rect, src.Rect)
+		}
+		for y := dst.Rect.Min.Y; y < dst.Rect.Max.Y; y++ {
+			for x := dst.Rect.Min.X; x < dst.Rect.Max.X; x++ {
+				if got := color.NRGBAModel.Convert(dst.RGBAAt(x, y)).(color.NRGBA); got != tt.want {
+					t.Fatalf("Invert(%v) at (%d,%d) = %v, want %v", tt.in, x, y, got, tt.want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package gifconv
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// JPEGSubsampling JPEG 色度子采样方式
+type JPEGSubsampling int
+
+const (
+	JPEGSubsample420 JPEGSubsampling = iota
+	JPEGSubsample422
+	JPEGSubsample444
+)
+
+// ParseJPEGSubsampling 解析色度子采样名称：444、422 或 420
+func ParseJPEGSubsampling(name string) (JPEGSubsampling, error) {
+	switch name {
+	case "420":
+		return JPEGSubsample420, nil
+	case "422":
+		return JPEGSubsample422, nil
+	case "444":
+		return JPEGSubsample444, nil
+	default:
+		return 0, fmt.Errorf("gifconv: unknown JPEG subsampling %q", name)
+	}
+}
+
+// EncodeJPEG 以指定质量（1-100）与色度子采样编码基线 JPEG。
+// image/jpeg 固定使用 4:2:0，因此 4:2:0 与灰度图像交给标准库，
+// 4:4:4 与 4:2:2 由本包的编码器写出，量化表与 Huffman 表与标准库相同
+func EncodeJPEG(w io.Writer, m image.Image, quality int, sub JPEGSubsampling) error {
+	if _, gray := m.(*image.Gray); gray || sub == JPEGSubsample420 {
+		return jpeg.Encode(w, m, &jpeg.Options{Quality: quality})
+	}
+	b := m.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() >= 1<<16 || b.Dy() >= 1<<16 {
+		return fmt.Errorf("gifconv: JPEG image size %dx%d out of range", b.Dx(), b.Dy())
+	}
+
+	e := &jpegEncoder{w: bufio.NewWriter(w)}
+	e.initQuant(quality)
+	hSamp := 1
+	if sub == JPEGSubsample422 {
+		hSamp = 2
+	}
+	e.writeHeaders(b.Size(), hSamp)
+	e.writeScan(m, hSamp)
+	e.emit(0x7f, 7) // 用 1 填充最后一个字节
+	e.write(0xff, 0xd9)
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// Z 字形顺序中第 n 个系数在 8x8 块中的自然顺序位置
+var jpegUnzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// 未缩放的亮度与色度量化表（Z 字形顺序），见规范 K.1
+var jpegUnscaledQuant = [2][64]byte{
+	{
+		16, 11, 12, 14, 12, 10, 16, 14,
+		13, 14, 18, 17, 16, 19, 24, 40,
+		26, 24, 22, 22, 24, 49, 35, 37,
+		29, 40, 58, 51, 61, 60, 57, 51,
+		56, 55, 64, 72, 92, 78, 64, 68,
+		87, 69, 55, 56, 80, 109, 81, 87,
+		95, 98, 103, 104, 103, 62, 77, 113,
+		121, 112, 100, 120, 92, 101, 103, 99,
+	},
+	{
+		17, 18, 18, 24, 21, 24, 47, 26,
+		26, 47, 99, 66, 56, 66, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	},
+}
+
+// Huffman 表：各码长的码字数与按码字顺序排列的取值
+type jpegHuffmanSpec struct {
+	count [16]byte
+	value []byte
+}
+
+// 亮度 DC、亮度 AC、色度 DC、色度 AC 的标准 Huffman 表，见规范 K.3
+var jpegHuffmanSpecs = [4]jpegHuffmanSpec{
+	{
+		[16]byte{0, 1, 5, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	},
+	{
+		[16]byte{0, 2, 1, 3, 3, 2, 4, 3, 5, 5, 4, 4, 0, 0, 1, 125},
+		[]byte{
+			0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12,
+			0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+			0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08,
+			0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+			0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16,
+			0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+			0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
+			0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+			0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59,
+			0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+			0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79,
+			0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+			0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98,
+			0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+			0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6,
+			0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+			0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4,
+			0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+			0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea,
+			0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	},
+	{
+		[16]byte{0, 3, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	},
+	{
+		[16]byte{0, 2, 1, 2, 4, 4, 3, 4, 7, 5, 4, 4, 0, 1, 2, 119},
+		[]byte{
+			0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21,
+			0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+			0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91,
+			0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+			0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34,
+			0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+			0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38,
+			0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+			0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58,
+			0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+			0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78,
+			0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+			0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96,
+			0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+			0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4,
+			0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+			0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2,
+			0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+			0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9,
+			0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	},
+}
+
+// 每个取值对应的码字：高 8 位为码长，低 24 位为码字
+var jpegHuffmanLUT [4][256]uint32
+
+func init() {
+	for n, spec := range jpegHuffmanSpecs {
+		code, k := uint32(0), 0
+		for i, count := range spec.count {
+			for j := byte(0); j < count; j++ {
+				jpegHuffmanLUT[n][spec.value[k]] = uint32(i+1)<<24 | code
+				code++
+				k++
+			}
+			code <<= 1
+		}
+	}
+}
+
+// DCT 系数表：jpegCos[x][u] = C(u)/2 * cos((2x+1)uπ/16)
+var jpegCos [8][8]float64
+
+func init() {
+	for x := 0; x < 8; x++ {
+		for u := 0; u < 8; u++ {
+			c := 0.5
+			if u == 0 {
+				c = 0.5 / math.Sqrt2
+			}
+			jpegCos[x][u] = c * math.Cos(float64(2*x+1)*float64(u)*math.Pi/16)
+		}
+	}
+}
+
+type jpegEncoder struct {
+	w     *bufio.Writer
+	err   error
+	bits  uint32
+	nBits uint
+	quant [2][64]int // Z 字形顺序
+}
+
+func (e *jpegEncoder) write(b ...byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+// 按质量缩放量化表，与 image/jpeg 的算法相同
+func (e *jpegEncoder) initQuant(quality int) {
+	quality = min(max(quality, 1), 100)
+	scale := 200 - quality*2
+	if quality < 50 {
+		scale = 5000 / quality
+	}
+	for i := range e.quant {
+		for z, q := range jpegUnscaledQuant[i] {
+			e.quant[i][z] = min(max((int(q)*scale+50)/100, 1), 255)
+		}
+	}
+}
+
+// 写出 SOI、DQT、SOF0、DHT 与 SOS，hSamp 为亮度的水平采样因子
+func (e *jpegEncoder) writeHeaders(size image.Point, hSamp int) {
+	e.write(0xff, 0xd8)
+	e.writeQuantTables()
+
+	e.write(0xff, 0xc0, 0, 8+3*3, 8,
+		byte(size.Y>>8), byte(size.Y), byte(size.X>>8), byte(size.X), 3,
+		1, byte(hSamp<<4|1), 0,
+		2, 0x11, 1,
+		3, 0x11, 1)
+
+	e.writeHuffmanTables()
+	e.write(0xff, 0xda, 0, 12, 3, 1, 0x00, 2, 0x11, 3, 0x11, 0, 63, 0)
+}
+
+// 写出亮度与色度量化表（DQT）
+func (e *jpegEncoder) writeQuantTables() {
+	e.write(0xff, 0xdb, 0, 2+2*65)
+	for i, table := range e.quant {
+		e.write(byte(i))
+		for _, q := range table {
+			e.write(byte(q))
+		}
+	}
+}
+
+// 写出四张标准 Huffman 表（DHT）
+func (e *jpegEncoder) writeHuffmanTables() {
+	length := 2
+	for _, spec := range jpegHuffmanSpecs {
+		length += 1 + 16 + len(spec.value)
+	}
+	e.write(0xff, 0xc4, byte(length>>8), byte(length))
+	for n, spec := range jpegHuffmanSpecs {
+		// 表类型（0 为 DC，1 为 AC）与表号（0 为亮度，1 为色度）
+		e.write(byte(n%2<<4 | n/2))
+		e.write(spec.count[:]...)
+		e.write(spec.value...)
+	}
+}
+
+// 写出 size 位的 bits，遇到 0xff 时补 0x00
+func (e *jpegEncoder) emit(bits uint32, size uint) {
+	e.bits = e.bits<<size | bits&(1<<size-1)
+	e.nBits += size
+	for e.nBits >= 8 {
+		b := byte(e.bits >> (e.nBits - 8))
+		e.write(b)
+		if b == 0xff {
+			e.write(0x00)
+		}
+		e.nBits -= 8
+	}
+}
+
+func (e *jpegEncoder) emitHuff(table int, value int) {
+	code := jpegHuffmanLUT[table][value]
+	e.emit(code&(1<<24-1), uint(code>>24))
+}
+
+// 写出前面有 run 个零系数的非零系数（或 DC 差值）value
+func (e *jpegEncoder) emitValue(table, run, value int) {
+	a, b := value, value
+	if a < 0 {
+		a, b = -value, value-1
+	}
+	n := bits.Len(uint(a))
+	e.emitHuff(table, run<<4|n)
+	if n > 0 {
+		e.emit(uint32(b), uint(n))
+	}
+}
+
+// 对一个 8x8 块做 DCT、量化与熵编码，返回本块的 DC 值供下一块差分
+func (e *jpegEncoder) writeBlock(block *[64]float64, component, prevDC int) int {
+	// 行列分离的二维 DCT
+	var tmp, coef [64]float64
+	for y := 0; y < 8; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < 8; x++ {
+				sum += block[y*8+x] * jpegCos[x][u]
+			}
+			tmp[y*8+u] = sum
+		}
+	}
+	for u := 0; u < 8; u++ {
+		for v := 0; v < 8; v++ {
+			var sum float64
+			for y := 0; y < 8; y++ {
+				sum += tmp[y*8+u] * jpegCos[y][v]
+			}
+			coef[v*8+u] = sum
+		}
+	}
+
+	q := &e.quant[min(component, 1)]
+	dcTable, acTable := 2*min(component, 1), 2*min(component, 1)+1
+	dc := int(math.Round(coef[0] / float64(q[0])))
+	e.emitValue(dcTable, 0, dc-prevDC)
+	run := 0
+	for z := 1; z < 64; z++ {
+		ac := int(math.Round(coef[jpegUnzig[z]] / float64(q[z])))
+		if ac == 0 {
+			run++
+			continue
+		}
+		for run > 15 {
+			e.emitHuff(acTable, 0xf0)
+			run -= 16
+		}
+		e.emitValue(acTable, run, ac)
+		run = 0
+	}
+	if run > 0 {
+		e.emitHuff(acTable, 0x00)
+	}
+	return dc
+}
+
+// 按 MCU 顺序写出扫描数据：每个 MCU 含 hSamp 个亮度块与各一个色度块，
+// 色度取水平方向 hSamp 个像素的平均值，超出图像的部分重复边缘像素
+func (e *jpegEncoder) writeScan(m image.Image, hSamp int) {
+	b := m.Bounds()
+	w, h := b.Dx(), b.Dy()
+	planes := [3][]float64{make([]float64, w*h), make([]float64, w*h), make([]float64, w*h)}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := m.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			yy, cb, cr := color.RGBToYCbCr(uint8(r>>8), uint8(g>>8), uint8(bl>>8))
+			planes[0][y*w+x] = float64(yy) - 128
+			planes[1][y*w+x] = float64(cb) - 128
+			planes[2][y*w+x] = float64(cr) - 128
+		}
+	}
+	at := func(plane []float64, x, y int) float64 {
+		return plane[min(y, h-1)*w+min(x, w-1)]
+	}
+
+	var block [64]float64
+	var dc [3]int
+	for my := 0; my < h; my += 8 {
+		for mx := 0; mx < w; mx += 8 * hSamp {
+			for n := 0; n < hSamp; n++ {
+				for j := 0; j < 8; j++ {
+					for i := 0; i < 8; i++ {
+						block[j*8+i] = at(planes[0], mx+n*8+i, my+j)
+					}
+				}
+				dc[0] = e.writeBlock(&block, 0, dc[0])
+			}
+			for c := 1; c < 3; c++ {
+				for j := 0; j < 8; j++ {
+					for i := 0; i < 8; i++ {
+						var sum float64
+						for k := 0; k < hSamp; k++ {
+							sum += at(planes[c], mx+i*hSamp+k, my+j)
+						}
+						block[j*8+i] = sum / float64(hSamp)
+					}
+				}
+				dc[c] = e.writeBlock(&block, c, dc[c])
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package gifconv
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Format Converter 编码单帧时使用的图像格式
+type Format int
+
+const (
+	FormatPNG Format = iota
+	FormatJPEG
+	FormatWebP
+	FormatBMP
+	FormatTIFF
+	FormatICO
+	FormatAVIF // 仅在以 -tags avif 构建时可用，见 AVIFSupported
+)
+
+// ConverterOption 配置 Converter，传给 NewConverter
+type ConverterOption func(*Converter)
+
+// WithFormat 设置 Encode 输出的图像格式，默认为 PNG
+func WithFormat(f Format) ConverterOption {
+	return func(c *Converter) { c.encode.Format = f }
+}
+
+// WithQuality 设置 JPEG、有损 WebP 与有损 AVIF 的质量（1-100），默认为 90
+func WithQuality(quality int) ConverterOption {
+	return func(c *Converter) { c.encode.Quality = quality }
+}
+
+// WithResize 将每帧缩放到指定尺寸，宽或高为 0 时按另一边保持宽高比；filter 为 nil 时使用 CatmullRom
+func WithResize(width, height int, filter xdraw.Interpolator) ConverterOption {
+	return func(c *Converter) {
+		c.width, c.height = width, height
+		c.filter = filter
+	}
+}
+
+// WithBackground 将每帧叠加到纯色背景上，输出不含透明像素的图像
+func WithBackground(bg color.Color) ConverterOption {
+	return func(c *Converter) { c.background = bg }
+}
+
+// WithBackgroundImage 先按 fit 绘制背景图像，再将每帧叠加在上面；同时设置 WithBackground 时背景色位于图像之下
+func WithBackgroundImage(img image.Image, fit BackgroundFit) ConverterOption {
+	return func(c *Converter) {
+		c.backgroundImage = img
+		c.backgroundFit = fit
+	}
+}
+
+// WithWorkers 设置 ConvertAll 并行处理帧的协程数，默认为 CPU 核数
+func WithWorkers(n int) ConverterOption {
+	return func(c *Converter) { c.workers = max(n, 1) }
+}
+
+// WithProgress 使 ConvertAll 每处理完一帧向 ch 发送进度。发送不会阻塞，接收方来不及处理时丢弃该次进度，
+// 因此应使用带缓冲的通道；ch 为 nil 时不报告进度
+func WithProgress(ch chan<- Progress) ConverterOption {
+	return func(c *Converter) { c.progress = ch }
+}
+
+// WithLossless 使 WebP 与 AVIF 使用无损编码
+func WithLossless(lossless bool) ConverterOption {
+	return func(c *Converter) { c.encode.Lossless = lossless }
+}
+
+// WithJPEGCMYK 使 JPEG 转换为 CMYK 后编码，见 EncodeJPEGCMYK，此时不使用色度子采样
+func WithJPEGCMYK(cmyk bool) ConverterOption {
+	return func(c *Converter) { c.encode.JPEGCMYK = cmyk }
+}
+
+// WithJPEGSubsampling 设置 JPEG 的色度子采样，默认为 4:2:0
+func WithJPEGSubsampling(sub JPEGSubsampling) ConverterOption {
+	return func(c *Converter) { c.encode.JPEGSubsampling = sub }
+}
+
+// WithPNGCompression 设置 PNG 的压缩级别
+func WithPNGCompression(level png.CompressionLevel) ConverterOption {
+	return func(c *Converter) { c.encode.PNGCompression = level }
+}
+
+// WithPaletted 使不超过 256 色的帧写为调色板 PNG
+func WithPaletted(paletted bool) ConverterOption {
+	return func(c *Converter) { c.encode.Paletted = paletted }
+}
+
+// WithColors 使 PNG 的每帧以中位切分法减少到最多 n 种颜色后写为调色板 PNG，dither 为 true 时使用 Floyd-Steinberg 抖动
+func WithColors(n int, dither bool) ConverterOption {
+	return func(c *Converter) {
+		c.encode.Colors = n
+		c.encode.Dither = dither
+	}
+}
+
+// WithInterlaced 使 PNG 写为 Adam7 交错格式，可在下载过程中逐步显示
+func WithInterlaced(interlaced bool) ConverterOption {
+	return func(c *Converter) { c.encode.Interlaced = interlaced }
+}
+
+// WithTIFFCompression 设置 TIFF 的压缩方式
+func WithTIFFCompression(compression TIFFCompression) ConverterOption {
+	return func(c *Converter) { c.encode.TIFFCompression = compression }
+}
+
+// WithICOSizes 设置 ICO 中包含的图标边长，默认为 DefaultICOSizes
+func WithICOSizes(sizes []int) ConverterOption {
+	return func(c *Converter) { c.encode.ICOSizes = sizes }
+}
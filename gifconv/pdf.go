@@ -0,0 +1,69 @@
+package gifconv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+)
+
+// EncodePDF 将各帧写为 PDF 文档，每帧一页，页面尺寸与图像相同（1 像素为 1 点）。
+// 图像以指定质量（1-100）的 JPEG 嵌入，透明像素应事先用 Flatten 合成到背景上
+func EncodePDF(w io.Writer, pages []image.Image, quality int) error {
+	if len(pages) == 0 {
+		return ErrNoFrames
+	}
+
+	// 对象编号：1 为目录，2 为页面树，之后每页依次为页面、内容流与图像
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...any) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]byte, 0, len(pages)*8)
+	for n := range pages {
+		kids = fmt.Appendf(kids, "%d 0 R ", 3+3*n)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids), len(pages))
+
+	for n, page := range pages {
+		size := page.Bounds().Size()
+		if size.X == 0 || size.Y == 0 {
+			return &EncodeError{Frame: n, Err: errors.New("gifconv: zero-size PDF page")}
+		}
+		var jpg bytes.Buffer
+		if err := EncodeJPEG(&jpg, page, quality, JPEGSubsample420); err != nil {
+			return &EncodeError{Frame: n, Err: err}
+		}
+		colorSpace := "/DeviceRGB"
+		if _, gray := page.(*image.Gray); gray {
+			colorSpace = "/DeviceGray"
+		}
+
+		pageObj := 3 + 3*n
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			size.X, size.Y, pageObj+2, pageObj+1)
+		content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", size.X, size.Y)
+		object("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+		object("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n%s\nendstream",
+			size.X, size.Y, colorSpace, jpg.Len(), jpg.Bytes())
+	}
+
+	// 交叉引用表的每一项固定为 20 字节
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,186 @@
+package gifconv
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"slices"
+)
+
+// Quantize 将图像转换为最多 256 色的调色板图像，半透明以下的像素视为全透明。
+// 颜色不超过 256 种时使用精确调色板，否则使用 Web 安全色并以 Floyd-Steinberg 抖动
+func Quantize(src image.Image) *image.Paletted {
+	if dst, ok := palettize(src, opaqueOrTransparent); ok {
+		return dst
+	}
+
+	bounds := src.Bounds()
+	pal := append(color.Palette{color.RGBA{}}, palette.WebSafe...)
+	dst := image.NewPaletted(bounds, pal)
+	draw.FloydSteinberg.Draw(dst, bounds, src, bounds.Min)
+	return dst
+}
+
+// QuantizeColors 用中位切分法将叠加后的图像减少到最多 n 种颜色（2-256），转换为调色板图像，
+// 颜色本来就不超过 n 种时保持不变。dither 为 true 时以 Floyd-Steinberg 抖动减轻色带，
+// 否则每个像素取调色板中最接近的颜色。透明度与颜色一同参与切分
+func QuantizeColors(src image.Image, n int, dither bool) *image.Paletted {
+	n = min(max(n, 2), 256)
+	bounds := src.Bounds()
+
+	// 统计各颜色的像素数
+	counts := make(map[color.NRGBA]int)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			counts[color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)]++
+		}
+	}
+	entries := make([]colorCount, 0, len(counts))
+	for c, count := range counts {
+		entries = append(entries, colorCount{c: [4]uint8{c.R, c.G, c.B, c.A}, count: count})
+	}
+
+	// 每次切分通道范围最大的颜色盒，直到达到 n 个或所有盒都只剩一种颜色
+	boxes := []colorBox{newColorBox(entries)}
+	for len(boxes) < n {
+		best := -1
+		for i, b := range boxes {
+			if len(b.entries) > 1 && (best < 0 || b.spread > boxes[best].spread ||
+				b.spread == boxes[best].spread && b.count > boxes[best].count) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		lo, hi := boxes[best].split()
+		boxes[best] = lo
+		boxes = append(boxes, hi)
+	}
+
+	pal := make(color.Palette, len(boxes))
+	for i, b := range boxes {
+		pal[i] = b.average()
+	}
+	dst := image.NewPaletted(bounds, pal)
+	if dither {
+		draw.FloydSteinberg.Draw(dst, bounds, src, bounds.Min)
+	} else {
+		draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+	}
+	return dst
+}
+
+// 一种颜色（R、G、B、A）及其像素数
+type colorCount struct {
+	c     [4]uint8
+	count int
+}
+
+// 中位切分中的颜色盒：spread 为范围最大的通道上的范围，channel 为该通道
+type colorBox struct {
+	entries []colorCount
+	count   int
+	channel int
+	spread  int
+}
+
+func newColorBox(entries []colorCount) colorBox {
+	b := colorBox{entries: entries}
+	lo := [4]uint8{0xff, 0xff, 0xff, 0xff}
+	var hi [4]uint8
+	for _, e := range entries {
+		b.count += e.count
+		for ch, v := range e.c {
+			lo[ch] = min(lo[ch], v)
+			hi[ch] = max(hi[ch], v)
+		}
+	}
+	for ch := range lo {
+		if spread := int(hi[ch]) - int(lo[ch]); spread > b.spread {
+			b.channel, b.spread = ch, spread
+		}
+	}
+	return b
+}
+
+// 沿范围最大的通道在像素数的中位数处将颜色盒分为两个。该通道上值相同的颜色分在同一侧，
+// 由于范围大于 0，两侧都至少包含一种颜色
+func (b colorBox) split() (colorBox, colorBox) {
+	ch := b.channel
+	slices.SortFunc(b.entries, func(x, y colorCount) int { return int(x.c[ch]) - int(y.c[ch]) })
+	median, sum := b.entries[0].c[ch], 0
+	for _, e := range b.entries {
+		sum += e.count
+		if sum*2 >= b.count {
+			median = e.c[ch]
+			break
+		}
+	}
+	// 中位数等于最大值时把它分到上半部分
+	mid, _ := slices.BinarySearchFunc(b.entries, median, func(e colorCount, v uint8) int {
+		if e.c[ch] <= v {
+			return -1
+		}
+		return 1
+	})
+	if mid == len(b.entries) {
+		mid, _ = slices.BinarySearchFunc(b.entries, median, func(e colorCount, v uint8) int { return int(e.c[ch]) - int(v) })
+	}
+	return newColorBox(b.entries[:mid]), newColorBox(b.entries[mid:])
+}
+
+// 颜色盒中各颜色按像素数加权的平均色
+func (b colorBox) average() color.NRGBA {
+	var sum [4]int
+	for _, e := range b.entries {
+		for ch, v := range e.c {
+			sum[ch] += int(v) * e.count
+		}
+	}
+	avg := func(ch int) uint8 { return uint8((sum[ch] + b.count/2) / b.count) }
+	return color.NRGBA{R: avg(0), G: avg(1), B: avg(2), A: avg(3)}
+}
+
+// Palettize 在图像不超过 256 种颜色时无损转换为调色板图像，否则返回 false
+func Palettize(src image.Image) (*image.Paletted, bool) {
+	return palettize(src, func(c color.Color) color.RGBA {
+		return color.RGBAModel.Convert(c).(color.RGBA)
+	})
+}
+
+// 以 convert 映射后的颜色建立精确调色板，超过 256 种颜色时返回 false
+func palettize(src image.Image, convert func(color.Color) color.RGBA) (*image.Paletted, bool) {
+	bounds := src.Bounds()
+	index := make(map[color.RGBA]uint8)
+	var pal color.Palette
+	pix := make([]uint8, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := convert(src.At(x, y))
+			i, ok := index[c]
+			if !ok {
+				if len(pal) == 256 {
+					return nil, false
+				}
+				i = uint8(len(pal))
+				index[c] = i
+				pal = append(pal, c)
+			}
+			pix = append(pix, i)
+		}
+	}
+	dst := image.NewPaletted(bounds, pal)
+	dst.Pix = pix
+	return dst, true
+}
+
+// GIF 只支持全透明，alpha 低于一半的像素视为透明，其余视为不透明
+func opaqueOrTransparent(c color.Color) color.RGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A < 0x80 {
+		return color.RGBA{}
+	}
+	return color.RGBA{R: n.R, G: n.G, B: n.B, A: 0xff}
+}
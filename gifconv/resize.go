@@ -0,0 +1,101 @@
+package gifconv
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ParseResizeFilter 将插值算法名称转换为对应的 Interpolator
+func ParseResizeFilter(name string) (xdraw.Interpolator, error) {
+	switch name {
+	case "nearest":
+		return xdraw.NearestNeighbor, nil
+	case "bilinear":
+		return xdraw.BiLinear, nil
+	case "catmull-rom":
+		return xdraw.CatmullRom, nil
+	default:
+		return nil, fmt.Errorf("gifconv: unknown resize filter %q", name)
+	}
+}
+
+// ResizeDimensions 计算缩放后的尺寸，宽或高为 0 时按另一边保持宽高比
+func ResizeDimensions(size image.Point, width, height int) image.Point {
+	switch {
+	case width == 0 && height == 0:
+		return size
+	case width == 0:
+		width = max(1, (size.X*height+size.Y/2)/size.Y)
+	case height == 0:
+		height = max(1, (size.Y*width+size.X/2)/size.X)
+	}
+	return image.Pt(width, height)
+}
+
+// FitDimensions 计算按比例缩小到宽高均不超过 limit 的尺寸，不会放大
+func FitDimensions(size image.Point, limit int) image.Point {
+	switch {
+	case size.X <= limit && size.Y <= limit:
+		return size
+	case size.X >= size.Y:
+		return image.Pt(limit, max(1, (size.Y*limit+size.X/2)/size.X))
+	default:
+		return image.Pt(max(1, (size.X*limit+size.Y/2)/size.Y), limit)
+	}
+}
+
+// Resize 将图像缩放到指定尺寸，宽或高为 0 时按另一边保持宽高比
+func Resize(src image.Image, width, height int, filter xdraw.Interpolator) image.Image {
+	size := ResizeDimensions(src.Bounds().Size(), width, height)
+	if size == src.Bounds().Size() {
+		return src
+	}
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	filter.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// ParseSize 解析 "WxH" 形式的尺寸，宽高均需为正数
+func ParseSize(s string) (image.Point, error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return image.Point{}, fmt.Errorf("gifconv: size %q must be WxH", s)
+	}
+	width, err1 := strconv.Atoi(strings.TrimSpace(w))
+	height, err2 := strconv.Atoi(strings.TrimSpace(h))
+	if err1 != nil || err2 != nil {
+		return image.Point{}, fmt.Errorf("gifconv: size %q must be WxH", s)
+	}
+	if width <= 0 || height <= 0 {
+		return image.Point{}, fmt.Errorf("gifconv: size %q must have positive width and height", s)
+	}
+	return image.Pt(width, height), nil
+}
+
+// Letterbox 按比例缩放图像使其恰好放入 size（必要时放大），居中放在 size 大小的画布上，
+// 其余部分以 bg 填充，bg 为 nil 时保持透明
+func Letterbox(src image.Image, size image.Point, bg color.Color, filter xdraw.Interpolator) *image.RGBA {
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	if bg != nil {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	}
+	s := src.Bounds().Size()
+	if s.X <= 0 || s.Y <= 0 {
+		return dst
+	}
+	scale := min(float64(size.X)/float64(s.X), float64(size.Y)/float64(s.Y))
+	fit := image.Pt(
+		min(size.X, max(1, int(math.Round(float64(s.X)*scale)))),
+		min(size.Y, max(1, int(math.Round(float64(s.Y)*scale)))),
+	)
+	offset := size.Sub(fit).Div(2)
+	filter.Scale(dst, image.Rectangle{Min: offset, Max: offset.Add(fit)}, src, src.Bounds(), xdraw.Over, nil)
+	return dst
+}
@@ -0,0 +1,142 @@
+package gifconv
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// NormalizeRotation 将旋转角度规整到 0、90、180 或 270，非 90 的倍数返回错误
+func NormalizeRotation(degrees int) (int, error) {
+	if degrees%90 != 0 {
+		return 0, fmt.Errorf("gifconv: rotation %d is not a multiple of 90 degrees", degrees)
+	}
+	return (degrees%360 + 360) % 360, nil
+}
+
+// Rotate 将图像顺时针旋转 degrees 度，degrees 需已规整为 0、90、180 或 270
+func Rotate(src image.Image, degrees int) *image.RGBA {
+	in := toRGBA(src)
+	w, h := in.Rect.Dx(), in.Rect.Dy()
+	dw, dh := w, h
+	if degrees == 90 || degrees == 270 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch degrees {
+			case 90:
+				dx, dy = h-1-y, x
+			case 180:
+				dx, dy = w-1-x, h-1-y
+			case 270:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			si := y*in.Stride + x*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], in.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// RotateAngle 将图像顺时针旋转任意角度（度），画布扩大到恰好容纳旋转后的图像，
+// 原图之外的角落为透明。像素按双线性插值采样，90 度的倍数与 Rotate 结果完全相同
+func RotateAngle(src image.Image, degrees float64) *image.RGBA {
+	degrees = normalizeAngle(degrees)
+	if n := int(degrees); float64(n) == degrees && n%90 == 0 {
+		return Rotate(src, n)
+	}
+
+	in := toRGBA(src)
+	w, h := in.Rect.Dx(), in.Rect.Dy()
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	dst := image.NewRGBA(image.Rectangle{Max: RotatedSize(image.Pt(w, h), degrees)})
+
+	// 以两幅图像的中心为原点，将目标像素中心逆向旋转回原图坐标
+	scx, scy := float64(w)/2, float64(h)/2
+	dcx, dcy := float64(dst.Rect.Dx())/2, float64(dst.Rect.Dy())/2
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			u, v := float64(x)+0.5-dcx, float64(y)+0.5-dcy
+			sx := u*cos + v*sin + scx - 0.5
+			sy := -u*sin + v*cos + scy - 0.5
+			c := bilinearRGBA(in, sx, sy)
+			copy(dst.Pix[y*dst.Stride+x*4:], c[:])
+		}
+	}
+	return dst
+}
+
+// RotatedSize 返回 RotateAngle 将 size 大小的图像旋转 degrees 度后的尺寸
+func RotatedSize(size image.Point, degrees float64) image.Point {
+	degrees = normalizeAngle(degrees)
+	if n := int(degrees); float64(n) == degrees && n%90 == 0 {
+		if n == 90 || n == 270 {
+			return image.Pt(size.Y, size.X)
+		}
+		return size
+	}
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	w, h := float64(size.X), float64(size.Y)
+	// 旋转后的外接矩形，减去很小的量避免浮点误差多出一行或一列
+	dw := int(math.Ceil(math.Abs(w*cos) + math.Abs(h*sin) - 1e-6))
+	dh := int(math.Ceil(math.Abs(w*sin) + math.Abs(h*cos) - 1e-6))
+	return image.Pt(max(dw, 1), max(dh, 1))
+}
+
+// 将角度规整到 [0, 360)
+func normalizeAngle(degrees float64) float64 {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+// 在 (x, y) 处对预乘 RGBA 图像双线性插值，落在图像之外的采样点视为透明，边缘因此平滑过渡
+func bilinearRGBA(m *image.RGBA, x, y float64) [4]uint8 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	var sum [4]float64
+	for _, s := range [4]struct {
+		dx, dy int
+		weight float64
+	}{
+		{0, 0, (1 - fx) * (1 - fy)},
+		{1, 0, fx * (1 - fy)},
+		{0, 1, (1 - fx) * fy},
+		{1, 1, fx * fy},
+	} {
+		px, py := ix+s.dx, iy+s.dy
+		if s.weight == 0 || px < 0 || py < 0 || px >= m.Rect.Dx() || py >= m.Rect.Dy() {
+			continue
+		}
+		off := py*m.Stride + px*4
+		for ch := range sum {
+			sum[ch] += float64(m.Pix[off+ch]) * s.weight
+		}
+	}
+	var c [4]uint8
+	for ch, v := range sum {
+		c[ch] = uint8(min(math.Round(v), 0xff))
+	}
+	return c
+}
+
+// 返回从原点开始的 RGBA 图像，已满足条件时直接返回原图
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,105 @@
+package gifconv
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// 40x20 的不透明渐变图像，各像素颜色互不相同以便发现方向错误
+func rotateSource() *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			m.SetRGBA(x, y, color.RGBA{uint8(x * 6), uint8(y * 12), 0x80, 0xff})
+		}
+	}
+	return m
+}
+
+func TestRotateAngleBounds(t *testing.T) {
+	tests := []struct {
+		degrees float64
+		want    image.Rectangle
+	}{
+		{45, image.Rect(0, 0, 43, 43)},
+		{30, image.Rect(0, 0, 45, 38)},
+		{60, image.Rect(0, 0, 38, 45)},
+		{135, image.Rect(0, 0, 43, 43)},
+	}
+	src := rotateSource()
+	for _, tt := range tests {
+		if got := RotateAngle(src, tt.degrees).Bounds(); got != tt.want {
+			t.Errorf("RotateAngle(%g) bounds = %v, want %v", tt.degrees, got, tt.want)
+		}
+	}
+}
+
+func TestRotatedSize(t *testing.T) {
+	// RotatedSize 与 RotateAngle 实际输出的尺寸一致
+	src := rotateSource()
+	for _, degrees := range []float64{0, 15, 30, 45, 90, 100, 135, 180, 270, 359.5, -30, 405} {
+		if got, want := RotatedSize(src.Rect.Size(), degrees), RotateAngle(src, degrees).Rect.Size(); got != want {
+			t.Errorf("RotatedSize(%g) = %v, want %v", degrees, got, want)
+		}
+	}
+}
+
+func TestRotateAngleRightAngles(t *testing.T) {
+	// 90 度的倍数（含负数与大于 360 的角度）与 Rotate 的结果逐字节相同
+	tests := []struct {
+		degrees float64
+		rotate  int
+	}{
+		{0, 0}, {90, 90}, {180, 180}, {270, 270},
+		{360, 0}, {450, 90}, {720, 0}, {-90, 270}, {-180, 180}, {-270, 90},
+	}
+	src := rotateSource()
+	for _, tt := range tests {
+		got, want := RotateAngle(src, tt.degrees), Rotate(src, tt.rotate)
+		if got.Rect != want.Rect || !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("RotateAngle(%g) differs from Rotate(%d)", tt.degrees, tt.rotate)
+		}
+	}
+}
+
+func TestRotateAngleTransparentCorners(t *testing.T) {
+	// 原图之外的角落为透明而不是黑色，中心仍为不透明的原图像素
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for i := 0; i < len(src.Pix); i += 4 {
+		src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = 0xff, 0xff, 0xff, 0xff
+	}
+	for _, degrees := range []float64{30, 45, -45, 200} {
+		dst := RotateAngle(src, degrees)
+		b := dst.Bounds()
+		for _, p := range []image.Point{{0, 0}, {b.Max.X - 1, 0}, {0, b.Max.Y - 1}, {b.Max.X - 1, b.Max.Y - 1}} {
+			if got := dst.RGBAAt(p.X, p.Y); got != (color.RGBA{}) {
+				t.Errorf("RotateAngle(%g) corner %v = %v, want transparent", degrees, p, got)
+			}
+		}
+		if got := dst.RGBAAt(b.Dx()/2, b.Dy()/2); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+			t.Errorf("RotateAngle(%g) center = %v, want opaque white", degrees, got)
+		}
+	}
+}
+
+func TestRotateAngleNormalizes(t *testing.T) {
+	// 负角度与 360 度以上的角度按模 360 处理
+	tests := []struct {
+		degrees, equivalent float64
+	}{
+		{-30, 330},
+		{-45, 315},
+		{390, 30},
+		{765, 45},
+		{-390, 330},
+	}
+	src := rotateSource()
+	for _, tt := range tests {
+		got, want := RotateAngle(src, tt.degrees), RotateAngle(src, tt.equivalent)
+		if got.Rect != want.Rect || !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("RotateAngle(%g) differs from RotateAngle(%g)", tt.degrees, tt.equivalent)
+		}
+	}
+}
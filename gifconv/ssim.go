@@ -0,0 +1,49 @@
+package gifconv
+
+import "image"
+
+// SSIM 计算窗口边长，图像边缘不足一个窗口的部分按实际大小计算
+const ssimWindow = 8
+
+// 8 位像素值下 SSIM 的稳定常数 (0.01·255)² 与 (0.03·255)²
+const (
+	ssimC1 = 6.5025
+	ssimC2 = 58.5225
+)
+
+// SSIM 计算两幅灰度图像的平均结构相似度：在互不重叠的 8×8 窗口内分别比较亮度、
+// 对比度与结构后取平均，结果不超过 1，完全相同时为 1。尺寸不同时返回 0
+func SSIM(a, b *image.Gray) float64 {
+	size := a.Rect.Size()
+	if size != b.Rect.Size() || size.X == 0 || size.Y == 0 {
+		return 0
+	}
+	total, windows := 0.0, 0
+	for y0 := 0; y0 < size.Y; y0 += ssimWindow {
+		for x0 := 0; x0 < size.X; x0 += ssimWindow {
+			x1, y1 := min(x0+ssimWindow, size.X), min(y0+ssimWindow, size.Y)
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			for y := y0; y < y1; y++ {
+				rowA := a.Pix[y*a.Stride:]
+				rowB := b.Pix[y*b.Stride:]
+				for x := x0; x < x1; x++ {
+					va, vb := float64(rowA[x]), float64(rowB[x])
+					sumA += va
+					sumB += vb
+					sumAA += va * va
+					sumBB += vb * vb
+					sumAB += va * vb
+				}
+			}
+			n := float64((x1 - x0) * (y1 - y0))
+			meanA, meanB := sumA/n, sumB/n
+			varA := sumAA/n - meanA*meanA
+			varB := sumBB/n - meanB*meanB
+			cov := sumAB/n - meanA*meanB
+			total += (2*meanA*meanB + ssimC1) * (2*cov + ssimC2) /
+				((meanA*meanA + meanB*meanB + ssimC1) * (varA + varB + ssimC2))
+			windows++
+		}
+	}
+	return total / float64(windows)
+}
@@ -0,0 +1,180 @@
+package gifconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+)
+
+// GIF 数据块标识
+const (
+	blockExtension  = 0x21
+	blockImage      = 0x2c
+	blockTrailer    = 0x3b
+	extGraphicCtrl  = 0xf9
+	extComment      = 0xfe
+	extApplication  = 0xff
+	colorTableFlag  = 0x80
+	colorTableSizes = 0x07
+)
+
+var errTruncated = errors.New("gifconv: truncated GIF")
+
+// FrameDecoder 从编码后的 GIF 数据中按需逐帧解码，任何时刻只保留当前帧的像素，
+// 用于无法一次性解码全部帧的大型动画
+type FrameDecoder struct {
+	data       []byte
+	header     []byte // 文件头、逻辑屏幕描述符与全局颜色表
+	frames     []frameSpan
+	extensions []Extension
+}
+
+// 单帧在数据中的位置：图形控制扩展（可能为空）与图像块
+type frameSpan struct {
+	control []byte
+	image   []byte
+}
+
+// NewFrameDecoder 扫描 GIF 的块结构但不解码像素，返回逐帧解码器及帧信息。
+// 返回的 GIF 中 Image 的各帧只有范围而不含像素，像素需通过 Frame 获取。
+// 块结构无效时返回 *DecodeError，不包含任何帧时返回 ErrNoFrames
+func NewFrameDecoder(data []byte) (*FrameDecoder, *gif.GIF, error) {
+	d, g, err := scanFrames(data)
+	if err != nil && err != ErrNoFrames {
+		return nil, nil, &DecodeError{Frame: -1, Err: err}
+	}
+	return d, g, err
+}
+
+func scanFrames(data []byte) (*FrameDecoder, *gif.GIF, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, nil, errors.New("gifconv: not a GIF file")
+	}
+	g := &gif.GIF{LoopCount: -1}
+	g.Config.Width = int(binary.LittleEndian.Uint16(data[6:8]))
+	g.Config.Height = int(binary.LittleEndian.Uint16(data[8:10]))
+	pos := 13
+	if data[10]&colorTableFlag != 0 {
+		pos += 3 << (data[10]&colorTableSizes + 1)
+	}
+	if pos > len(data) {
+		return nil, nil, errTruncated
+	}
+	// 与 gif.DecodeAll 一样通过 Config.ColorModel 提供全局颜色表
+	if pos > 13 {
+		palette := make(color.Palette, 0, (pos-13)/3)
+		for p := 13; p < pos; p += 3 {
+			palette = append(palette, color.RGBA{data[p], data[p+1], data[p+2], 0xff})
+		}
+		g.Config.ColorModel = palette
+	}
+	d := &FrameDecoder{data: data, header: data[:pos]}
+
+	var control []byte
+	for {
+		if pos >= len(data) {
+			return nil, nil, errTruncated
+		}
+		start := pos
+		switch data[pos] {
+		case blockExtension:
+			if pos+2 > len(data) {
+				return nil, nil, errTruncated
+			}
+			label := data[pos+1]
+			end, err := skipSubBlocks(data, pos+2)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch {
+			case label == extGraphicCtrl && end-start >= 8:
+				control = data[start:end]
+			case label == extApplication && end-start >= 19 && string(data[pos+3:pos+14]) == "NETSCAPE2.0":
+				g.LoopCount = int(binary.LittleEndian.Uint16(data[pos+16 : pos+18]))
+			}
+			if ext, ok := parseExtension(data[start:end], len(d.frames)); ok {
+				d.extensions = append(d.extensions, ext)
+			}
+			pos = end
+
+		case blockImage:
+			if pos+10 > len(data) {
+				return nil, nil, errTruncated
+			}
+			desc := data[pos+1 : pos+10]
+			left := int(binary.LittleEndian.Uint16(desc[0:2]))
+			top := int(binary.LittleEndian.Uint16(desc[2:4]))
+			width := int(binary.LittleEndian.Uint16(desc[4:6]))
+			height := int(binary.LittleEndian.Uint16(desc[6:8]))
+			pos += 10
+			if desc[8]&colorTableFlag != 0 {
+				pos += 3 << (desc[8]&colorTableSizes + 1)
+			}
+			// 跳过 LZW 最小码长字节与图像数据子块
+			end, err := skipSubBlocks(data, pos+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			pos = end
+
+			delay, disposal := 0, byte(0)
+			if control != nil {
+				disposal = (control[3] >> 2) & 0x07
+				delay = int(binary.LittleEndian.Uint16(control[4:6]))
+			}
+			d.frames = append(d.frames, frameSpan{control: control, image: data[start:end]})
+			g.Image = append(g.Image, &image.Paletted{Rect: image.Rect(left, top, left+width, top+height)})
+			g.Delay = append(g.Delay, delay)
+			g.Disposal = append(g.Disposal, disposal)
+			control = nil
+
+		case blockTrailer:
+			if len(d.frames) == 0 {
+				return nil, nil, ErrNoFrames
+			}
+			return d, g, nil
+
+		default:
+			return nil, nil, fmt.Errorf("gifconv: unknown block type 0x%02x", data[pos])
+		}
+	}
+}
+
+// 跳过从 pos 开始的子块序列，返回结束块之后的位置
+func skipSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return 0, errTruncated
+		}
+		n := int(data[pos])
+		pos++
+		if n == 0 {
+			return pos, nil
+		}
+		pos += n
+	}
+}
+
+// Frame 解码第 i 帧的像素，数据无效时返回 *DecodeError
+func (d *FrameDecoder) Frame(i int) (*image.Paletted, error) {
+	if i < 0 || i >= len(d.frames) {
+		return nil, fmt.Errorf("gifconv: frame index %d out of range [0, %d)", i, len(d.frames))
+	}
+	// 拼出只含这一帧的 GIF 交给 image/gif 解码
+	span := d.frames[i]
+	var single bytes.Buffer
+	single.Grow(len(d.header) + len(span.control) + len(span.image) + 1)
+	single.Write(d.header)
+	single.Write(span.control)
+	single.Write(span.image)
+	single.WriteByte(blockTrailer)
+	g, err := gif.DecodeAll(&single)
+	if err != nil {
+		return nil, &DecodeError{Frame: i, Err: err}
+	}
+	return g.Image[0], nil
+}
@@ -0,0 +1,99 @@
+package gifconv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// TextEntry 嵌入图像文件的一条文本元数据
+type TextEntry struct {
+	Key, Value string
+}
+
+// PNG 文件签名与 IHDR 块的总长度，文本块插在 IHDR 之后
+const pngHeaderLen = 8 + 12 + 13
+
+// EmbedText 返回一个写入器，将写入的 PNG 或 JPEG 数据转发给 w，并在其中插入文本元数据：
+// PNG 在 IHDR 之后写入 tEXt 块（值含非 ASCII 字符时为 UTF-8 的 iTXt 块），
+// JPEG 在 SOI 之后写入一个 COM 注释段，每条为一行 "Key: Value"。其他数据原样写出
+func EmbedText(w io.Writer, entries []TextEntry) io.Writer {
+	return &textWriter{w: w, entries: entries}
+}
+
+type textWriter struct {
+	w       io.Writer
+	entries []TextEntry
+	head    []byte // 尚未能判断格式时缓存的文件开头
+	done    bool
+}
+
+func (t *textWriter) Write(p []byte) (int, error) {
+	if t.done {
+		return t.w.Write(p)
+	}
+	t.head = append(t.head, p...)
+
+	var split int
+	var insert []byte
+	switch {
+	case len(t.head) >= pngHeaderLen && bytes.HasPrefix(t.head, pngSignature):
+		split, insert = pngHeaderLen, pngTextChunks(t.entries)
+	case len(t.head) >= 2 && t.head[0] == 0xff && t.head[1] == 0xd8:
+		split, insert = 2, jpegComment(t.entries)
+	case len(t.head) < pngHeaderLen && bytes.HasPrefix(pngSignature, t.head[:min(len(t.head), len(pngSignature))]),
+		len(t.head) < 2 && t.head[0] == 0xff:
+		// 数据还不足以判断格式
+		return len(p), nil
+	}
+
+	t.done = true
+	head := t.head
+	t.head = nil
+	for _, b := range [][]byte{head[:split], insert, head[split:]} {
+		if _, err := t.w.Write(b); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// 将各条文本编码为 PNG 的 tEXt 或 iTXt 块
+func pngTextChunks(entries []TextEntry) []byte {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		var data []byte
+		if isASCII(e.Value) {
+			data = append([]byte(e.Key), 0)
+			data = append(data, e.Value...)
+			writePNGChunk(&buf, "tEXt", data)
+		} else {
+			// 不压缩，语言标签与翻译后的关键字为空
+			data = append([]byte(e.Key), 0, 0, 0, 0, 0)
+			data = append(data, e.Value...)
+			writePNGChunk(&buf, "iTXt", data)
+		}
+	}
+	return buf.Bytes()
+}
+
+// 将各条文本编码为 JPEG 的 COM 段，过长时截断到单个段的上限
+func jpegComment(entries []TextEntry) []byte {
+	var text []byte
+	for _, e := range entries {
+		text = append(text, e.Key+": "+e.Value+"\n"...)
+	}
+	text = text[:min(len(text), 0xffff-2)]
+	seg := []byte{0xff, 0xfe, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(text)+2))
+	return append(seg, text...)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,286 @@
+package gifconv
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"sort"
+)
+
+// TIFFCompression TIFF 像素数据的压缩方式
+type TIFFCompression int
+
+const (
+	TIFFNone TIFFCompression = iota
+	TIFFDeflate
+	TIFFLZW
+)
+
+// ParseTIFFCompression 解析压缩方式名称：none、deflate 或 lzw
+func ParseTIFFCompression(name string) (TIFFCompression, error) {
+	switch name {
+	case "none":
+		return TIFFNone, nil
+	case "deflate":
+		return TIFFDeflate, nil
+	case "lzw":
+		return TIFFLZW, nil
+	default:
+		return 0, fmt.Errorf("gifconv: unknown TIFF compression %q", name)
+	}
+}
+
+// TIFF 规范中的压缩方式取值
+func (c TIFFCompression) specValue() uint16 {
+	switch c {
+	case TIFFDeflate:
+		return 8
+	case TIFFLZW:
+		return 5
+	default:
+		return 1
+	}
+}
+
+// TIFF 字段类型
+const (
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+type tiffEntry struct {
+	tag    uint16
+	typ    uint16
+	values []uint32
+}
+
+// EncodeTIFF 将各帧写为 TIFF 文件，多于一帧时每帧为一页。
+// 像素以 8 位非预乘 RGBA 存储，整页为一个条带。
+func EncodeTIFF(w io.Writer, pages []image.Image, compression TIFFCompression) error {
+	if len(pages) == 0 {
+		return ErrNoFrames
+	}
+
+	// 文件头：小端字节序，首个 IFD 紧随其后
+	var buf bytes.Buffer
+	buf.WriteString("II*\x00")
+	binary.Write(&buf, binary.LittleEndian, uint32(8))
+
+	for n, page := range pages {
+		size := page.Bounds().Size()
+		if size.X == 0 || size.Y == 0 {
+			return &EncodeError{Frame: n, Err: errors.New("gifconv: zero-size TIFF page")}
+		}
+		data, err := compressTIFFStrip(page, compression)
+		if err != nil {
+			return &EncodeError{Frame: n, Err: err}
+		}
+
+		entries := []tiffEntry{
+			{256, tiffLong, []uint32{uint32(size.X)}},
+			{257, tiffLong, []uint32{uint32(size.Y)}},
+			{258, tiffShort, []uint32{8, 8, 8, 8}},
+			{259, tiffShort, []uint32{uint32(compression.specValue())}},
+			{262, tiffShort, []uint32{2}}, // RGB
+			{273, tiffLong, []uint32{0}},  // 条带偏移，稍后填入
+			{277, tiffShort, []uint32{4}},
+			{278, tiffLong, []uint32{uint32(size.Y)}},
+			{279, tiffLong, []uint32{uint32(len(data))}},
+			{282, tiffRational, []uint32{72, 1}},
+			{283, tiffRational, []uint32{72, 1}},
+			{296, tiffShort, []uint32{2}}, // 英寸
+			{338, tiffShort, []uint32{2}}, // 非预乘 alpha
+		}
+		if len(pages) > 1 {
+			// NewSubfileType 与 PageNumber 标记多页文档，字段须按标签升序排列
+			entries = append(entries,
+				tiffEntry{254, tiffLong, []uint32{2}},
+				tiffEntry{297, tiffShort, []uint32{uint32(n), uint32(len(pages))}})
+			sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+		}
+
+		// 布局：IFD、超出 4 字节的字段值、像素数据
+		ifdStart := buf.Len()
+		extraStart := ifdStart + 2 + 12*len(entries) + 4
+		extraLen := 0
+		for _, e := range entries {
+			if l := e.size(); l > 4 {
+				extraLen += l
+			}
+		}
+		dataStart := extraStart + extraLen
+		nextIFD := 0
+		if n < len(pages)-1 {
+			nextIFD = dataStart + len(data) + len(data)%2
+		}
+		for i := range entries {
+			if entries[i].tag == 273 {
+				entries[i].values[0] = uint32(dataStart)
+			}
+		}
+
+		binary.Write(&buf, binary.LittleEndian, uint16(len(entries)))
+		var extra bytes.Buffer
+		for _, e := range entries {
+			binary.Write(&buf, binary.LittleEndian, e.tag)
+			binary.Write(&buf, binary.LittleEndian, e.typ)
+			binary.Write(&buf, binary.LittleEndian, e.count())
+			if e.size() > 4 {
+				binary.Write(&buf, binary.LittleEndian, uint32(extraStart+extra.Len()))
+				e.writeValues(&extra)
+			} else {
+				var inline bytes.Buffer
+				e.writeValues(&inline)
+				inline.Write(make([]byte, 4-inline.Len()))
+				buf.Write(inline.Bytes())
+			}
+		}
+		binary.Write(&buf, binary.LittleEndian, uint32(nextIFD))
+		buf.Write(extra.Bytes())
+		buf.Write(data)
+		// IFD 必须从偶数偏移开始
+		if len(data)%2 == 1 {
+			buf.WriteByte(0)
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (e tiffEntry) count() uint32 {
+	if e.typ == tiffRational {
+		return uint32(len(e.values) / 2)
+	}
+	return uint32(len(e.values))
+}
+
+func (e tiffEntry) size() int {
+	if e.typ == tiffShort {
+		return 2 * len(e.values)
+	}
+	return 4 * len(e.values)
+}
+
+func (e tiffEntry) writeValues(w io.Writer) {
+	for _, v := range e.values {
+		if e.typ == tiffShort {
+			binary.Write(w, binary.LittleEndian, uint16(v))
+		} else {
+			binary.Write(w, binary.LittleEndian, v)
+		}
+	}
+}
+
+// 将一页图像转换为 RGBA 字节并按指定方式压缩
+func compressTIFFStrip(img image.Image, compression TIFFCompression) ([]byte, error) {
+	bounds := img.Bounds()
+	raw := make([]byte, 0, 4*bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			raw = append(raw, c.R, c.G, c.B, c.A)
+		}
+	}
+
+	switch compression {
+	case TIFFDeflate:
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(raw); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case TIFFLZW:
+		return compressTIFFLZW(raw), nil
+	default:
+		return raw, nil
+	}
+}
+
+// TIFF LZW 编码：高位在前，码宽比 GIF 的 LZW 提前一个码字增长
+func compressTIFFLZW(data []byte) []byte {
+	const (
+		codeClear = 256
+		codeEOI   = 257
+		codeFirst = 258
+		codeMax   = 4095
+		minWidth  = 9
+	)
+	var bw tiffBitWriter
+	width := uint(minWidth)
+	next := codeFirst
+	table := make(map[uint32]int)
+
+	// 每加入一个新码字后检查是否需要清表或加宽码字
+	grow := func() {
+		switch {
+		case next == codeMax-1:
+			bw.write(codeClear, width)
+			clear(table)
+			width = minWidth
+			next = codeFirst
+		case next > 1<<width-1:
+			width++
+		}
+	}
+
+	bw.write(codeClear, width)
+	if len(data) == 0 {
+		bw.write(codeEOI, width)
+		return bw.flush()
+	}
+	prefix := int(data[0])
+	for _, c := range data[1:] {
+		key := uint32(prefix)<<8 | uint32(c)
+		if code, ok := table[key]; ok {
+			prefix = code
+			continue
+		}
+		bw.write(prefix, width)
+		table[key] = next
+		next++
+		grow()
+		prefix = int(c)
+	}
+	bw.write(prefix, width)
+	next++
+	grow()
+	bw.write(codeEOI, width)
+	return bw.flush()
+}
+
+// 高位在前的位写入器
+type tiffBitWriter struct {
+	buf  []byte
+	acc  uint32
+	bits uint
+}
+
+func (b *tiffBitWriter) write(code int, width uint) {
+	b.acc = b.acc<<width | uint32(code)
+	b.bits += width
+	for b.bits >= 8 {
+		b.bits -= 8
+		b.buf = append(b.buf, byte(b.acc>>b.bits))
+	}
+	b.acc &= 1<<b.bits - 1
+}
+
+func (b *tiffBitWriter) flush() []byte {
+	if b.bits > 0 {
+		b.buf = append(b.buf, byte(b.acc<<(8-b.bits)))
+		b.bits = 0
+		b.acc = 0
+	}
+	return b.buf
+}
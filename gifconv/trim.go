@@ -0,0 +1,40 @@
+package gifconv
+
+import "image"
+
+// ContentBounds 返回图像中不完全透明的像素的最小外接矩形，图像完全透明时返回空矩形
+func ContentBounds(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	var content image.Rectangle
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !opaqueAt(img, x, y) {
+				continue
+			}
+			if content.Empty() {
+				content = image.Rect(x, y, x+1, y+1)
+			} else {
+				content = content.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return content
+}
+
+// 判断像素是否含有不透明的部分
+func opaqueAt(img image.Image, x, y int) bool {
+	if rgba, ok := img.(*image.RGBA); ok {
+		return rgba.Pix[rgba.PixOffset(x, y)+3] != 0
+	}
+	_, _, _, a := img.At(x, y).RGBA()
+	return a != 0
+}
+
+// Trim 裁掉图像四周完全透明的行与列，返回从原点开始的新图像；图像完全透明时原样返回
+func Trim(img image.Image) image.Image {
+	r := ContentBounds(img)
+	if r.Empty() || r == img.Bounds() {
+		return img
+	}
+	return Crop(img, r)
+}
@@ -0,0 +1,59 @@
+package gifconv
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// WatermarkPosition 水印所在的角落
+type WatermarkPosition int
+
+const (
+	WatermarkBottomRight WatermarkPosition = iota
+	WatermarkBottomLeft
+	WatermarkTopRight
+	WatermarkTopLeft
+)
+
+// 水印与帧边缘之间的距离（像素），帧太小时按比例缩小
+const watermarkMargin = 8
+
+// ParseWatermarkPosition 解析水印位置名称：top-left、top-right、bottom-left 或 bottom-right
+func ParseWatermarkPosition(name string) (WatermarkPosition, error) {
+	switch name {
+	case "bottom-right":
+		return WatermarkBottomRight, nil
+	case "bottom-left":
+		return WatermarkBottomLeft, nil
+	case "top-right":
+		return WatermarkTopRight, nil
+	case "top-left":
+		return WatermarkTopLeft, nil
+	default:
+		return 0, fmt.Errorf("gifconv: unknown watermark position %q", name)
+	}
+}
+
+// Watermark 按 opacity（0-1）将水印图像以 alpha 混合绘制到帧的指定角落，返回新图像。
+// 水印比帧大时超出部分被裁掉
+func Watermark(src, mark image.Image, pos WatermarkPosition, opacity float64) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, src, bounds.Min, draw.Src)
+
+	size := mark.Bounds().Size()
+	margin := min(watermarkMargin, bounds.Dx()/20, bounds.Dy()/20)
+	at := image.Pt(bounds.Max.X-margin-size.X, bounds.Max.Y-margin-size.Y)
+	if pos == WatermarkTopLeft || pos == WatermarkBottomLeft {
+		at.X = bounds.Min.X + margin
+	}
+	if pos == WatermarkTopLeft || pos == WatermarkTopRight {
+		at.Y = bounds.Min.Y + margin
+	}
+	alpha := image.NewUniform(color.Alpha{A: uint8(math.Round(opacity * 0xff))})
+	draw.DrawMask(dst, image.Rectangle{Min: at, Max: at.Add(size)}, mark, mark.Bounds().Min, alpha, image.Point{}, draw.Over)
+	return dst
+}
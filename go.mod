@@ -0,0 +1,11 @@
+module github.com/makotome/gif2png
+
+go 1.26.0
+
+require (
+	github.com/chai2010/webp v1.4.0
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410
+	golang.org/x/term v0.46.0
+)
+
+require golang.org/x/sys v0.48.0 // indirect
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"html/template"
+	"os"
+	"sort"
+)
+
+// 预览页中的一帧：帧序号、相对输出目录的文件名与延迟（毫秒），按输出序号排列
+type htmlFrame struct {
+	Index int
+	File  string
+	Delay int
+	order int
+}
+
+var htmlPreview = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 16px; }
+#player img { image-rendering: pixelated; border: 1px solid #ccc; }
+.frames { display: flex; flex-wrap: wrap; gap: 12px; margin-top: 16px; }
+figure { margin: 0; text-align: center; font-size: 12px; }
+figure img { border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div id="player">
+<button id="toggle">Play</button> <span id="label"></span><br>
+<img id="current" src="{{(index .Frames 0).File}}" alt="">
+</div>
+<div class="frames">
+{{- range .Frames}}
+<figure><img src="{{.File}}" alt="frame {{.Index}}"><figcaption>frame {{.Index}} &middot; {{.Delay}} ms</figcaption></figure>
+{{- end}}
+</div>
+<script>
+var frames = [{{range $i, $f := .Frames}}{{if $i}}, {{end}}{file: {{$f.File}}, index: {{$f.Index}}, delay: {{$f.Delay}}}{{end}}];
+var current = 0, timer = null;
+var img = document.getElementById("current"), label = document.getElementById("label"), toggle = document.getElementById("toggle");
+function show(n) {
+	current = n;
+	img.src = frames[n].file;
+	label.textContent = "frame " + frames[n].index + " (" + frames[n].delay + " ms)";
+}
+function step() {
+	show((current + 1) % frames.length);
+	timer = setTimeout(step, frames[current].delay || 100);
+}
+toggle.onclick = function () {
+	if (timer) {
+		clearTimeout(timer);
+		timer = null;
+		toggle.textContent = "Play";
+	} else {
+		toggle.textContent = "Pause";
+		timer = setTimeout(step, frames[current].delay || 100);
+	}
+};
+show(0);
+</script>
+</body>
+</html>
+`))
+
+// 写出逐帧输出的 HTML 预览页，帧按输出序号排列；与浏览器播放 GIF 一致，延迟为 0 的帧按 100 ms 播放
+func writeHTMLPreview(path, title string, frames []htmlFrame) error {
+	if len(frames) == 0 {
+		return nil
+	}
+	sort.Slice(frames, func(a, b int) bool { return frames[a].order < frames[b].order })
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := htmlPreview.Execute(file, struct {
+		Title  string
+		Frames []htmlFrame
+	}{title, frames}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
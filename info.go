@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 打印 GIF 的帧数、尺寸、循环次数与总时长，不写出任何文件
+func printInfo(ctx context.Context, input string) error {
+	gifImg, _, _, err := decodeInput(ctx, input, false, false)
+	if err != nil {
+		return err
+	}
+	meta := newGIFMetadata(gifImg)
+	total := 0
+	for _, frame := range meta.Frames {
+		total += frame.Delay
+	}
+	fmt.Printf("%s\n", input)
+	if meta.FrameCount == 1 {
+		fmt.Printf("  frames: 1 (static image)\n")
+	} else {
+		fmt.Printf("  frames: %d\n", meta.FrameCount)
+	}
+	fmt.Printf("  size: %dx%d\n", meta.Width, meta.Height)
+	fmt.Printf("  %s\n", loopSummary(meta.LoopCount))
+	fmt.Printf("  duration: %s\n", time.Duration(total)*10*time.Millisecond)
+	return nil
+}
+
+// 检查输入能否完整解码为 GIF，成功时打印帧数
+func validateGIF(ctx context.Context, input string) error {
+	gifImg, _, _, err := decodeInput(ctx, input, false, false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: ok, %d frames\n", input, len(gifImg.Image))
+	return nil
+}
+
+// 逐帧打印范围、偏移、处置方法、延迟与是否含透明像素。只解码各帧像素以检查透明，不做叠加
+func printFrameList(ctx context.Context, input string) error {
+	gifImg, _, _, err := decodeInput(ctx, input, false, false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", input)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  FRAME\tSIZE\tOFFSET\tDISPOSAL\tDELAY\tTRANSPARENT")
+	for i, frame := range gifImg.Image {
+		var disposal byte
+		if i < len(gifImg.Disposal) {
+			disposal = gifImg.Disposal[i]
+		}
+		delay := 0
+		if i < len(gifImg.Delay) {
+			delay = gifImg.Delay[i]
+		}
+		bounds := frame.Bounds()
+		transparent := "no"
+		if hasTransparentPixels(frame) {
+			transparent = "yes"
+		}
+		fmt.Fprintf(tw, "  %d\t%dx%d\t%d,%d\t%s\t%s\t%s\n", i, bounds.Dx(), bounds.Dy(), bounds.Min.X, bounds.Min.Y,
+			gifconv.DisposalName(disposal), time.Duration(delay)*10*time.Millisecond, transparent)
+	}
+	return tw.Flush()
+}
+
+// 判断帧中是否有像素使用调色板中的透明色
+func hasTransparentPixels(frame *image.Paletted) bool {
+	var transparent [256]bool
+	found := false
+	for n, c := range frame.Palette {
+		if _, _, _, a := c.RGBA(); a == 0 {
+			transparent[n] = true
+			found = true
+		}
+	}
+	if !found {
+		return false
+	}
+	for _, p := range frame.Pix {
+		if transparent[p] {
+			return true
+		}
+	}
+	return false
+}
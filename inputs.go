@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 待转换的输入文件及其输出目录
+type conversion struct {
+	input     string
+	outputDir string
+}
+
+// 展开输入列表：通配符模式先用 filepath.Glob 展开，普通文件与 URL 直接转换，
+// 目录在 -recursive 下递归查找 .gif 与 .gif.gz 文件，并在输出目录下保留相同的子目录结构。
+// 未指定 -output 时，每个输入（或输入目录）的输出目录以其基本名称命名
+func expandInputs(patterns []string, opts *options) ([]conversion, error) {
+	inputs, err := expandGlobs(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var conversions []conversion
+	for _, input := range inputs {
+		outputDir := opts.outputDir
+		if opts.autoOutputDir {
+			outputDir = filepath.Join(outputDir, inputBaseName(input))
+		}
+		if input == "-" || isURL(input) {
+			conversions = append(conversions, conversion{input: input, outputDir: outputDir})
+			continue
+		}
+
+		info, err := os.Stat(input)
+		if err != nil || !info.IsDir() {
+			// 无法访问的文件留给转换阶段报告错误
+			conversions = append(conversions, conversion{input: input, outputDir: outputDir})
+			continue
+		}
+		if !opts.recursive {
+			return nil, fmt.Errorf("%s is a directory (use -recursive to convert its contents)", input)
+		}
+
+		err = filepath.WalkDir(input, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if !strings.EqualFold(filepath.Ext(trimGzipExt(path)), ".gif") {
+				opts.debugf("Skipping non-GIF file %s", path)
+				return nil
+			}
+			rel, err := filepath.Rel(input, filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			conversions = append(conversions, conversion{input: path, outputDir: filepath.Join(outputDir, rel)})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walking %s: %w", input, err)
+		}
+	}
+	return conversions, nil
+}
+
+// 返回输入的基本名称（不含扩展名），标准输入为 "stdin"，URL 取路径中的文件名
+func inputBaseName(input string) string {
+	switch {
+	case input == "-":
+		return "stdin"
+	case isURL(input):
+		return urlBaseName(input)
+	default:
+		base := trimGzipExt(filepath.Base(input))
+		return base[:len(base)-len(filepath.Ext(base))]
+	}
+}
+
+// 去掉 gzip 压缩输入的 .gz 扩展名，如 cat.gif.gz → cat.gif
+func trimGzipExt(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".gz") {
+		return name[:len(name)-len(".gz")]
+	}
+	return name
+}
+
+// 返回嵌入元数据时记录的输入名称：本地文件为文件名，URL 原样保留
+func inputSourceName(input string) string {
+	switch {
+	case input == "-":
+		return "stdin"
+	case isURL(input):
+		return input
+	default:
+		return filepath.Base(input)
+	}
+}
+
+// 展开包含通配符的输入，已存在的同名文件按字面路径处理
+func expandGlobs(patterns []string) ([]string, error) {
+	var inputs []string
+	for _, pattern := range patterns {
+		if isURL(pattern) || !strings.ContainsAny(pattern, "*?[") {
+			inputs = append(inputs, pattern)
+			continue
+		}
+		if _, err := os.Stat(pattern); err == nil {
+			inputs = append(inputs, pattern)
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match pattern %q", pattern)
+		}
+		inputs = append(inputs, matches...)
+	}
+	return inputs, nil
+}
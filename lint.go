@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image/gif"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 处置方法或帧范围中的一处可疑设置
+type lintIssue struct {
+	frame   int
+	message string
+}
+
+// 检查容易在不同查看器中渲染不一致的处置方法与帧范围，只读取帧信息而不解码像素
+func lintDisposal(g *gif.GIF) []lintIssue {
+	var issues []lintIssue
+	canvas := gifconv.CanvasBounds(g)
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		switch {
+		case i == 0 && disposal == gif.DisposalPrevious:
+			issues = append(issues, lintIssue{i, "disposal previous on the first frame has no earlier canvas to restore; viewers clear it or keep it"})
+		case disposal > gif.DisposalPrevious:
+			issues = append(issues, lintIssue{i, fmt.Sprintf("unknown disposal code %d; viewers treat it as none or unspecified", disposal)})
+		}
+		if !frame.Bounds().In(canvas) {
+			issues = append(issues, lintIssue{i, fmt.Sprintf("bounds %v extend beyond the %dx%d logical screen; viewers clip or grow the canvas", frame.Bounds(), canvas.Dx(), canvas.Dy())})
+		}
+	}
+
+	// 循环播放时第 0 帧叠加在最后一帧处置后的画布上，部分查看器会先清空画布
+	last := len(g.Image) - 1
+	if last > 0 && g.LoopCount != -1 && g.Image[0].Bounds() != canvas {
+		var disposal byte
+		if last < len(g.Disposal) {
+			disposal = g.Disposal[last]
+		}
+		if disposal != gif.DisposalBackground && disposal != gif.DisposalPrevious {
+			issues = append(issues, lintIssue{0, "does not cover the whole canvas and the last frame is not disposed, so some viewers show the last frame through it when looping"})
+		}
+	}
+	return issues
+}
+
+// 打印输入中可疑的处置方法，返回发现的问题数；不写出任何文件
+func lintGIF(ctx context.Context, input string) (int, error) {
+	gifImg, _, _, err := decodeInput(ctx, input, true, false)
+	if err != nil {
+		return 0, err
+	}
+	issues := lintDisposal(gifImg)
+	for _, issue := range issues {
+		fmt.Printf("%s: frame %d: %s\n", input, issue.frame, issue.message)
+	}
+	if len(issues) == 0 {
+		fmt.Printf("%s: no disposal issues\n", input)
+	}
+	return len(issues), nil
+}
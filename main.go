@@ -1,16 +1,24 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"image"
-	"image/draw"
-	"image/gif"
-	"image/jpeg"
+	"image/color"
 	"image/png"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makotome/gif2png/gifconv"
+	xdraw "golang.org/x/image/draw"
 )
 
 type OutputFormat int
@@ -18,156 +26,1060 @@ type OutputFormat int
 const (
 	FormatPNG OutputFormat = iota
 	FormatJPG
+	FormatWebP
+	FormatAPNG
+	FormatBMP
+	FormatTIFF
+	FormatAWebP
+	FormatICO
+	FormatPDF
+	FormatAVIF
 )
 
-// GIF disposal methods
-const (
-	disposalNone       = 0x01
-	disposalBackground = 0x02
-	disposalPrevious   = 0x03
-)
+// 判断格式是否将所有帧写入同一个动画文件
+func (f OutputFormat) animated() bool {
+	return f == FormatAPNG || f == FormatAWebP
+}
 
-// 将GIF帧转换为完整图像
-func gifFrameToImage(gif *gif.GIF, frame int) *image.RGBA {
-	bounds := gif.Image[frame].Bounds()
-	img := image.NewRGBA(bounds)
-
-	// 如果是第一帧，直接复制
-	if frame == 0 {
-		drawFrame(img, gif.Image[frame], bounds)
-		return img
-	}
-
-	// 对于后续帧，需要考虑前面所有帧的叠加效果
-	for i := 0; i <= frame; i++ {
-		disposal := uint8(0)
-		if i < len(gif.Disposal) {
-			disposal = gif.Disposal[i]
-		}
-
-		switch disposal {
-		case disposalNone:
-			drawFrame(img, gif.Image[i], bounds)
-		case disposalBackground:
-			// 清除为背景色
-			draw.Draw(img, bounds, image.Transparent, image.Point{}, draw.Src)
-			drawFrame(img, gif.Image[i], bounds)
-		case disposalPrevious:
-			if i > 0 {
-				// 保存当前状态
-				temp := image.NewRGBA(bounds)
-				draw.Draw(temp, bounds, img, bounds.Min, draw.Over)
-				// 绘制新帧
-				drawFrame(img, gif.Image[i], bounds)
-				// 恢复之前的状态
-				draw.Draw(img, bounds, temp, bounds.Min, draw.Over)
-			} else {
-				drawFrame(img, gif.Image[i], bounds)
-			}
-		default:
-			drawFrame(img, gif.Image[i], bounds)
+// 返回单帧编码使用的 gifconv 格式，动画格式的各帧分别按 PNG 与 WebP 编码，PDF 各页按 JPEG 编码
+func (f OutputFormat) stillFormat() gifconv.Format {
+	switch f {
+	case FormatJPG, FormatPDF:
+		return gifconv.FormatJPEG
+	case FormatWebP, FormatAWebP:
+		return gifconv.FormatWebP
+	case FormatBMP:
+		return gifconv.FormatBMP
+	case FormatTIFF:
+		return gifconv.FormatTIFF
+	case FormatICO:
+		return gifconv.FormatICO
+	case FormatAVIF:
+		return gifconv.FormatAVIF
+	default:
+		return gifconv.FormatPNG
+	}
+}
+
+// 解析输出格式名称
+func parseFormat(name string) (OutputFormat, error) {
+	switch name {
+	case "jpg", "jpeg":
+		return FormatJPG, nil
+	case "png":
+		return FormatPNG, nil
+	case "webp":
+		return FormatWebP, nil
+	case "apng":
+		return FormatAPNG, nil
+	case "awebp":
+		return FormatAWebP, nil
+	case "bmp":
+		return FormatBMP, nil
+	case "tiff", "tif":
+		return FormatTIFF, nil
+	case "ico":
+		return FormatICO, nil
+	case "pdf":
+		return FormatPDF, nil
+	case "avif":
+		return FormatAVIF, nil
+	default:
+		return 0, fmt.Errorf("unsupported format: %s", name)
+	}
+}
+
+// -sizes 中的一种输出比例，suffix 为文件扩展名之前的尺寸后缀，1x 没有后缀
+type outputScale struct {
+	factor float64
+	suffix string
+}
+
+// 解析以逗号分隔的输出比例，如 "1x,0.5x,0.25x"，重复的比例只保留一个
+func parseScales(s string) ([]outputScale, error) {
+	var scales []outputScale
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		factor, err := strconv.ParseFloat(strings.TrimSuffix(part, "x"), 64)
+		if err != nil || !strings.HasSuffix(part, "x") || factor <= 0 || math.IsInf(factor, 0) {
+			return nil, fmt.Errorf("invalid size %q, expected a scale such as 0.5x", part)
+		}
+		if slices.ContainsFunc(scales, func(s outputScale) bool { return s.factor == factor }) {
+			continue
+		}
+		scale := outputScale{factor: factor}
+		if factor != 1 {
+			scale.suffix = "@" + strconv.FormatFloat(factor, 'g', -1, 64) + "x"
 		}
+		scales = append(scales, scale)
 	}
+	return scales, nil
+}
 
+// 解析 PNG 压缩级别名称
+func parsePNGCompression(name string) (png.CompressionLevel, error) {
+	switch name {
+	case "default":
+		return png.DefaultCompression, nil
+	case "none":
+		return png.NoCompression, nil
+	case "best-speed":
+		return png.BestSpeed, nil
+	case "best-compression":
+		return png.BestCompression, nil
+	default:
+		return 0, fmt.Errorf("unsupported PNG compression: %s", name)
+	}
+}
+
+// 对所有输入文件生效的转换选项
+type options struct {
+	outputDir       string
+	autoOutputDir   bool
+	format          OutputFormat
+	converter       *gifconv.Converter
+	extraFormats    []formatOutput
+	scales          []outputScale // -sizes 中的各输出比例
+	scale           outputScale   // 单个输出变体的比例
+	multipage       bool
+	tiffCompression gifconv.TIFFCompression
+	quality         int
+	baseName        string
+	nameTemplate    *nameTemplate
+	combineLayout   string
+	forceFrames     bool
+	staticImage     bool // 当前输入只有一帧，逐帧输出不带帧序号
+	singleFrame     bool
+	frameIndex      int
+	outputFile      string
+	zipPath         string
+	thumbnail       bool
+	flattenFinal    bool
+	start           int
+	end             int
+	step            int
+	every           int
+	at              []float64
+	fps             float64
+	dedupe          bool
+	dedupeSSIM      float64 // 大于 0 时按结构相似度去重，同时设置 dedupe
+	reverse         bool
+	indexOffset     int
+	width           int
+	height          int
+	maxDimension    int
+	fitSize         image.Point // -fit-size，为零时不缩放填充
+	fitBackground   color.Color // -fit-size 填充区域的颜色，为 nil 时透明
+	filter          xdraw.Interpolator
+	crop            image.Rectangle
+	trim            bool
+	trimUniform     bool
+	rotate          int
+	rotateDeg       float64
+	flipH           bool
+	flipV           bool
+	grayscale       bool
+	brightness      float64
+	contrast        float64
+	invert          bool
+	gamma           *gifconv.GammaTable
+	watermark       image.Image
+	watermarkPos    gifconv.WatermarkPosition
+	watermarkAlpha  float64
+	timingFile      string
+	timingCSV       string
+	exportPalette   string
+	metadata        bool
+	readExtensions  bool
+	embedMetadata   bool
+	sourceName      string // -embed-metadata 时写入各帧的输入文件名
+	spriteSheet     bool
+	columns         int
+	dataURI         string
+	html            bool
+	montage         bool
+	diff            bool
+	montageColumns  int
+	montagePadding  int
+	montageLabel    bool
+	lowMemory       bool
+	maxFrames       int
+	truncateFrames  bool
+	failFast        bool
+	workers         int
+	fileWorkers     int
+	noClobber       bool
+	resume          bool
+	dryRun          bool
+	quiet           bool
+	recursive       bool
+	verbose         bool
+	timer           *stageTimer // -profile 时累计各阶段耗时，否则为 nil
+}
+
+// 单帧处理：裁剪、旋转、镜像、合成背景、缩放与灰度转换
+func (o *options) process(img image.Image) image.Image {
+	defer o.timer.since(stageProcess, time.Now())
+	// 裁剪区域在写出任何帧之前已校验并限制到画布范围内
+	if !o.crop.Empty() {
+		img = gifconv.Crop(img, o.crop)
+	}
+	if o.trim {
+		img = gifconv.Trim(img)
+	}
+	if o.rotate != 0 {
+		img = gifconv.Rotate(img, o.rotate)
+	}
+	if o.rotateDeg != 0 {
+		img = gifconv.RotateAngle(img, o.rotateDeg)
+	}
+	if o.flipH || o.flipV {
+		img = gifconv.Flip(img, o.flipH, o.flipV)
+	}
+	if o.brightness != 0 || o.contrast != 1 {
+		img = gifconv.Adjust(img, o.brightness, o.contrast)
+	}
+	if o.gamma != nil {
+		img = o.gamma.Apply(img)
+	}
+	if o.invert {
+		img = gifconv.Invert(img)
+	}
+	if o.fitSize != (image.Point{}) {
+		img = gifconv.Letterbox(img, o.fitSize, o.fitBackground, o.filter)
+	}
+	img = o.converter.Process(img)
+	if o.maxDimension > 0 {
+		size := gifconv.FitDimensions(img.Bounds().Size(), o.maxDimension)
+		img = gifconv.Resize(img, size.X, size.Y, o.filter)
+	}
+	if o.watermark != nil {
+		img = gifconv.Watermark(img, o.watermark, o.watermarkPos, o.watermarkAlpha)
+	}
+	if o.scale.factor > 0 && o.scale.factor != 1 {
+		size := img.Bounds().Size()
+		img = gifconv.Resize(img, max(1, int(math.Round(float64(size.X)*o.scale.factor))), max(1, int(math.Round(float64(size.Y)*o.scale.factor))), o.filter)
+	}
+	if o.grayscale {
+		img = gifconv.Grayscale(img)
+	}
 	return img
 }
 
-// 绘制单个帧
-func drawFrame(dst *image.RGBA, src *image.Paletted, bounds image.Rectangle) {
-	draw.Draw(dst, bounds, src, bounds.Min, draw.Over)
+// 逗号分隔的 -format 中除第一种之外的输出格式及其编码器
+type formatOutput struct {
+	format    OutputFormat
+	converter *gifconv.Converter
+}
+
+// 返回每种输出格式与 -sizes 比例各自的选项：只有一种格式与比例时为 o 本身，
+// 否则为替换了格式、编码器与比例的副本，同一格式的各比例相邻
+func (o *options) formatVariants() []*options {
+	if len(o.extraFormats) == 0 && len(o.scales) == 0 {
+		return []*options{o}
+	}
+	outputs := append([]formatOutput{{format: o.format, converter: o.converter}}, o.extraFormats...)
+	scales := o.scales
+	if len(scales) == 0 {
+		scales = []outputScale{{factor: 1}}
+	}
+	var variants []*options
+	for _, out := range outputs {
+		for _, s := range scales {
+			v := *o
+			v.format, v.converter, v.extraFormats = out.format, out.converter, nil
+			v.scales, v.scale = nil, s
+			variants = append(variants, &v)
+		}
+	}
+	return variants
+}
+
+// 逐帧输出文件的扩展名，带有 -sizes 的尺寸后缀，如 "@0.5x.png"
+func (o *options) frameExt() string {
+	return o.scale.suffix + formatExt(o.format)
+}
+
+// 输出常规信息到标准输出，-quiet 时不输出
+func (o *options) infof(format string, args ...any) {
+	if !o.quiet {
+		fmt.Printf(format+"\n", args...)
+	}
+}
+
+// 输出详细信息到标准输出，仅在 -verbose 时输出
+func (o *options) debugf(format string, args ...any) {
+	if o.verbose {
+		fmt.Printf(format+"\n", args...)
+	}
+}
+
+// 判断输出文件是否已存在且无需重新写出：-no-clobber 时只要存在即可，
+// -resume 时空文件视为上次中断时未写完
+func (o *options) existingOutput(path string) bool {
+	if !o.noClobber && !o.resume {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return o.noClobber || info.Size() > 0
+}
+
+// 启用 -no-clobber 或 -resume 且输出文件已存在时返回 true 并记录跳过，默认直接覆盖
+func (o *options) skipExisting(path string) bool {
+	if !o.existingOutput(path) {
+		return false
+	}
+	if !o.quiet {
+		log.Printf("Skipping existing file %s", path)
+	}
+	return true
+}
+
+// 可重复指定的字符串参数
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 func main() {
 	// 定义命令行参数
-	inputFile := flag.String("input", "", "Input GIF file path")
-	outputDir := flag.String("output", "", "Output directory for image files")
-	format := flag.String("format", "png", "Output format: png or jpg")
-	quality := flag.Int("quality", 90, "JPEG quality (1-100)")
+	var inputs stringList
+	flag.Var(&inputs, "input", "Input GIF file path, glob pattern, http(s) URL, or - to read from stdin (repeatable; trailing arguments are also inputs)")
+	outputDir := flag.String("output", "", "Output directory for image files (a file path, or - for stdout, with -frame); defaults to a directory named after each input")
+	format := flag.String("format", "png", "Output format: png, jpg, webp, apng, awebp (animated WebP), bmp, tiff, ico, pdf (one page per frame) or avif (builds with -tags avif)")
+	quality := flag.Int("quality", 90, "JPEG/WebP/AVIF quality (1-100), also used for the images embedded in PDF pages")
+	lossless := flag.Bool("lossless", false, "Use lossless WebP and AVIF encoding")
+	jpegSubsample := flag.String("jpeg-subsample", "420", "JPEG chroma subsampling: 444, 422 or 420")
+	jpegCMYK := flag.Bool("jpeg-cmyk", false, "Write JPEGs in CMYK (naive conversion without an ICC profile, transparency over white) for print workflows")
+	pngCompression := flag.String("png-compression", "default", "PNG compression: default, none, best-speed or best-compression")
+	colors := flag.Int("colors", 0, "Quantize each PNG frame to at most this many colors (2-256) with median cut and write paletted PNGs")
+	dither := flag.Bool("dither", false, "Use Floyd-Steinberg dithering with -colors to reduce banding")
+	progressive := flag.Bool("progressive", false, "Write Adam7-interlaced PNGs that browsers display progressively while loading")
+	paletted := flag.Bool("paletted", false, "Write 8-bit paletted PNGs when a frame has at most 256 colors")
+	multipage := flag.Bool("multipage", false, "Write all selected frames into a single multi-page TIFF (always on for -format pdf)")
+	tiffCompression := flag.String("tiff-compression", "none", "TIFF compression: none, deflate or lzw")
+	icoSizes := flag.String("ico-sizes", "16,32,48", "Comma-separated icon sizes (1-256 pixels) embedded in -format ico output")
+	baseName := flag.String("basename", "", "Base name for output files (default: input file name, or \"stdin\")")
+	forceFrames := flag.Bool("force-frames", false, "Keep indexed frame names for single-frame GIFs instead of writing <base>.<ext>")
+	pad := flag.Int("pad", 0, "Zero-pad width of frame numbers in the default file names (0 picks one from the frame count, at least 3)")
+	nameTmpl := flag.String("name-template", defaultNameTemplate, "Per-frame file name template with {base}, {index}, {index:04d}, {ext} (with dot) and {delay} (1/100 s)")
+	frameIndex := flag.Int("frame", 0, "Extract only this frame index (negative counts from the end); -output may then be a file path")
+	thumbnail := flag.Bool("thumbnail", false, "Write only frame 0 as <base>.<ext>, e.g. for a static preview")
+	flattenFinal := flag.Bool("flatten-final", false, "Write only the final accumulated image, after compositing every frame with its disposal, as <base>_final.<ext>")
+	start := flag.Int("start", 0, "First frame index to write")
+	end := flag.Int("end", -1, "Last frame index to write, inclusive (-1 means last frame)")
+	step := flag.Int("step", 1, "Write every n-th frame within the range")
+	every := flag.Int("every", 1, "Keep only every n-th frame of the whole animation (frame indices divisible by n)")
+	at := flag.String("at", "", "Write only the frames visible at these comma-separated times in seconds (e.g. 0.5,1.0,2.0)")
+	indexOffset := flag.Int("index-offset", 0, "Add this to frame numbers in file names, timing, metadata and atlas output, e.g. 1 to start at 1")
+	reverse := flag.Bool("reverse", false, "Write frames in reverse order, numbering the last frame first")
+	dedupe := flag.Bool("dedupe", false, "Skip frames identical to the previously written one (animated formats merge their delays)")
+	dedupeSSIM := flag.Float64("dedupe-ssim", 0, "Like -dedupe, but skip frames whose grayscale SSIM to the previously written one exceeds this threshold (0-1), e.g. 0.98")
+	width := flag.Int("width", 0, "Output width in pixels (0 keeps aspect ratio from -height)")
+	height := flag.Int("height", 0, "Output height in pixels (0 keeps aspect ratio from -width)")
+	fitSize := flag.String("fit-size", "", "Scale each frame to fit within WxH keeping its aspect ratio and pad the rest with -background (transparent if unset) to exactly WxH")
+	maxDimension := flag.Int("max-dimension", 0, "Scale frames down so neither side exceeds this many pixels (never upscales)")
+	outputSizes := flag.String("sizes", "", "Also write each frame at these scales, e.g. 1x,0.5x,0.25x, with suffixed names like _frame_003@0.5x.png")
+	resizeFilter := flag.String("resize-filter", "catmull-rom", "Resize interpolation: nearest, bilinear or catmull-rom")
+	crop := flag.String("crop", "", "Crop each frame to x,y,w,h before resizing (clamped to the frame)")
+	trim := flag.Bool("trim", false, "Crop fully transparent rows and columns around each frame's content")
+	trimUniform := flag.Bool("trim-uniform", false, "Like -trim, but crop every frame to the union of their content so they stay aligned")
+	rotate := flag.Int("rotate", 0, "Rotate each frame clockwise by 90, 180 or 270 degrees")
+	rotateDeg := flag.Float64("rotate-deg", 0, "Rotate each frame clockwise by any angle in degrees, enlarging the canvas with transparent corners")
+	flipH := flag.Bool("flip-h", false, "Mirror each frame horizontally")
+	flipV := flag.Bool("flip-v", false, "Mirror each frame vertically")
+	grayscale := flag.Bool("grayscale", false, "Convert each frame to 8-bit grayscale (drops transparency)")
+	gamma := flag.Float64("gamma", 1, "Apply this gamma to every color channel (above 1 brightens midtones, below 1 darkens)")
+	invert := flag.Bool("invert", false, "Write the photographic negative of each frame (alpha untouched; applied before -grayscale)")
+	brightness := flag.Float64("brightness", 0, "Add this much to every color channel, from -1 (black) to 1 (white)")
+	contrast := flag.Float64("contrast", 1, "Scale every color channel around mid-gray by this factor (1 keeps the original, 0 gives flat gray)")
+	background := flag.String("background", "", "Color for transparent pixels in JPEG output and with -flatten, e.g. #ffffff")
+	backgroundImage := flag.String("background-image", "", "Composite every frame over this image (PNG, JPEG, GIF, WebP or BMP), drawn above -background if both are set")
+	backgroundFit := flag.String("background-fit", "stretch", "How -background-image fills each frame: stretch, tile or cover")
+	flatten := flag.Bool("flatten", false, "Composite every frame onto -background (default white) for fully opaque output in any format")
+	watermark := flag.String("watermark", "", "Stamp this image (PNG, JPEG, GIF, WebP or BMP) onto a corner of every exported frame")
+	watermarkPos := flag.String("watermark-pos", "bottom-right", "Watermark corner: top-left, top-right, bottom-left or bottom-right")
+	watermarkOpacity := flag.Float64("watermark-opacity", 1, "Watermark opacity from 0 (invisible) to 1 (opaque)")
+	timingFile := flag.String("timing-file", "", "Write a CSV of frame index, delay and cumulative time (1/100 s) to this path")
+	timingCSV := flag.String("timing-csv", "", "Write a CSV of each output frame's delay, cumulative time (ms) and disposal, plus a total-duration row, to this path")
+	exportPalette := flag.String("export-palette", "", "Write the GIF's global color table (or the first frame's palette) to this GIMP .gpl file, even with -dry-run")
+	metadata := flag.Bool("metadata", false, "Write <base>_meta.json describing the GIF into the output directory")
+	readExtensions := flag.Bool("read-extensions", false, "With -metadata, also list the GIF's comment and application extension blocks (e.g. XMP)")
+	embedMetadata := flag.Bool("embed-metadata", false, "Embed the source file name, frame index, delay and software in each frame: PNG text chunks or a JPEG comment")
+	zipPath := flag.String("zip", "", "Write the frames into this .zip archive instead of loose files in the output directory")
+	spriteSheet := flag.Bool("spritesheet", false, "Combine the selected frames into a single sprite sheet with a JSON atlas")
+	columns := flag.Int("columns", 0, "Sprite sheet columns (0 puts all frames in one row)")
+	dataURI := flag.String("data-uri", "", "Write all frames as base64 data URIs into one file: lines (one per line) or json (an array)")
+	html := flag.Bool("html", false, "Write an index.html preview of the written frames with play/pause at the real timing")
+	diff := flag.Bool("diff", false, "Instead of the frames, write <base>_diff images showing the pixels each frame changes (cleared pixels in magenta)")
+	montage := flag.Bool("montage", false, "Lay out the selected frames in a single overview grid image")
+	montageColumns := flag.Int("montage-cols", 0, "Montage columns (0 picks a roughly square grid)")
+	montagePadding := flag.Int("montage-padding", 4, "Montage spacing between and around frames in pixels")
+	montageLabel := flag.Bool("montage-label", false, "Label each montage frame with its index")
+	lowMemory := flag.Bool("low-memory", false, "Decode frames one at a time instead of loading the whole GIF (best with per-frame output)")
+	maxFrames := flag.Int("max-frames", 0, "Refuse GIFs with more frames than this (0 means no limit); with -low-memory the count is checked before any pixels are decoded")
+	truncateFrames := flag.Bool("truncate-frames", false, "With -max-frames, convert only the first frames of longer GIFs and print a warning instead of failing")
+	failFast := flag.Bool("fail-fast", false, "Stop at the first frame or file that fails instead of continuing")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of concurrent frame encoders")
+	fileWorkers := flag.Int("file-workers", 1, "Number of input files converted concurrently")
+	noClobber := flag.Bool("no-clobber", false, "Skip output files that already exist instead of overwriting them")
+	resume := flag.Bool("resume", false, "Skip frames whose output files already exist and are non-empty, to restart an interrupted conversion")
+	dryRun := flag.Bool("dry-run", false, "Decode and print the files that would be written without touching the disk")
+	quiet := flag.Bool("quiet", false, "Print only final summaries and errors")
+	timeout := flag.Duration("timeout", 0, "Stop converting or inspecting, including downloads, after this long, e.g. 30s (0 means no limit)")
+	recursive := flag.Bool("recursive", false, "Convert every .gif under input directories, mirroring subdirectories in the output")
+	info := flag.Bool("info", false, "Print frame count, dimensions, loop count and total duration of each input, then exit (no -output needed)")
+	listFrames := flag.Bool("list-frames", false, "Print a table of each frame's size, offset, disposal, delay and transparency, then exit (no -output needed)")
+	lint := flag.Bool("lint", false, "Print warnings about disposal methods and frame bounds that render inconsistently across viewers, then exit (no -output needed)")
+	lintStrict := flag.Bool("lint-strict", false, "Like -lint, but exit non-zero when any issue is found")
+	validate := flag.Bool("validate", false, "Check that each input is a fully decodable GIF, exiting non-zero on failure (no -output needed)")
+	rebuild := flag.String("rebuild", "", "Re-assemble the PNG/JPG frames in this directory into the GIF at -output (delays from -timing-file if given)")
+	fps := flag.Float64("fps", 0, "Resample animated output (and -timing-file) to a constant frame rate, duplicating or dropping frames")
+	delay := flag.Int("delay", 10, "Per-frame delay in 1/100 s for -rebuild when no timing file is given")
+	loopCount := flag.Int("loop-count", 0, "Loop count for -rebuild (0 loops forever, -1 plays once)")
+	combine := flag.Bool("combine", false, "Place all inputs side by side in one set of frames, matched by frame index (shorter animations hold their last frame)")
+	combineLayout := flag.String("combine-layout", combineHorizontal, "Layout for -combine: horizontal (one row) or grid (roughly square)")
+	verbose := flag.Bool("verbose", false, "Print additional details, such as each frame's disposal, bounds and delay")
+	profile := flag.Bool("profile", false, "Write CPU and memory pprof profiles and print per-stage timings (decode, composite, process, encode, write)")
+	cpuProfile := flag.String("cpu-profile", "cpu.pprof", "CPU profile path for -profile")
+	memProfile := flag.String("mem-profile", "mem.pprof", "Memory profile path for -profile")
+	flag.Usage = printUsage
 	flag.Parse()
+	inputs = append(inputs, flag.Args()...)
+
+	// 记录显式指定的参数
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	// 重建模式：将帧目录重新编码为 GIF
+	if *rebuild != "" {
+		if *outputDir == "" {
+			log.Fatal("-rebuild requires -output <file.gif>")
+		}
+		if *delay < 0 {
+			log.Fatal("Delay must not be negative")
+		}
+		if err := rebuildGIF(*rebuild, *outputDir, *timingFile, *delay, *loopCount); err != nil {
+			log.Fatalf("Error rebuilding GIF: %v", err)
+		}
+		return
+	}
+
+	// 整个转换或检查过程共用一个超时，Ctrl-C 或 SIGTERM 同样在当前帧完成后停止
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	ctx, interrupted := cancelOnSignal(ctx)
+
+	// 信息、校验与检查模式：只解码输入，打印统计信息、逐帧详情、校验结果或可疑的处置方法
+	if *info || *listFrames || *validate || *lint || *lintStrict {
+		if len(inputs) == 0 {
+			log.Fatal("-info, -list-frames, -validate and -lint require at least one input")
+		}
+		paths, err := expandGlobs(inputs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		inspect, verb := printInfo, "reading"
+		issues := 0
+		switch {
+		case *listFrames:
+			inspect = printFrameList
+		case *validate:
+			inspect, verb = validateGIF, "validating"
+		case *lint || *lintStrict:
+			inspect, verb = func(ctx context.Context, input string) error {
+				n, err := lintGIF(ctx, input)
+				issues += n
+				return err
+			}, "linting"
+		}
+		failed := false
+		for _, input := range paths {
+			if err := inspect(ctx, input); err != nil {
+				log.Printf("Error %s %s: %v", verb, input, err)
+				failed = true
+			}
+		}
+		if failed || (*lintStrict && issues > 0) {
+			os.Exit(1)
+		}
+		return
+	}
 
 	// 检查必需参数
-	if *inputFile == "" || *outputDir == "" {
-		fmt.Println("Usage: gifconvert -input <gif_file> -output <output_directory> [-format <png|jpg>] [-quality <1-100>]")
-		flag.PrintDefaults()
+	if len(inputs) == 0 {
+		flag.Usage()
 		return
 	}
 
-	// 确定输出格式
-	var outputFormat OutputFormat
-	switch *format {
-	case "jpg", "jpeg":
-		outputFormat = FormatJPG
-	case "png":
-		outputFormat = FormatPNG
-	default:
-		log.Fatalf("Unsupported format: %s", *format)
+	// 仅指定 -zip 时，元数据等附带文件写入压缩包所在目录；
+	// 两者都未指定时，每个输入写入以其文件名命名的目录（cat.gif → ./cat/）
+	autoOutputDir := false
+	if *outputDir == "" {
+		if *zipPath != "" {
+			*outputDir = filepath.Dir(*zipPath)
+		} else {
+			*outputDir = "."
+			autoOutputDir = true
+		}
 	}
 
-	// 验证质量参数
-	if outputFormat == FormatJPG && (*quality < 1 || *quality > 100) {
+	// ICO 只包含一帧（默认第 0 帧，写为 <base>.ico），-output 以 .ico 结尾时同样视为 ICO
+	icoOutput := *format == "ico" || (!setFlags["format"] && strings.EqualFold(filepath.Ext(*outputDir), ".ico"))
+	if icoOutput && (setFlags["start"] || setFlags["end"] || setFlags["step"] || setFlags["every"] || *at != "" ||
+		*spriteSheet || *montage || *multipage || *zipPath != "" || *dataURI != "" || *html) {
+		log.Fatal("-format ico writes a single frame and cannot be combined with -start, -end, -step, -every, -at, -spritesheet, -montage, -multipage, -zip, -data-uri or -html")
+	}
+
+	opts := &options{
+		outputDir:      *outputDir,
+		autoOutputDir:  autoOutputDir,
+		zipPath:        *zipPath,
+		multipage:      *multipage,
+		quality:        *quality,
+		baseName:       *baseName,
+		singleFrame:    setFlags["frame"] || *thumbnail || *flattenFinal || icoOutput,
+		frameIndex:     *frameIndex,
+		thumbnail:      *thumbnail || (icoOutput && !setFlags["frame"] && !*flattenFinal),
+		flattenFinal:   *flattenFinal,
+		start:          *start,
+		end:            *end,
+		step:           *step,
+		every:          *every,
+		fps:            *fps,
+		dedupe:         *dedupe || setFlags["dedupe-ssim"],
+		dedupeSSIM:     *dedupeSSIM,
+		forceFrames:    *forceFrames,
+		combineLayout:  *combineLayout,
+		reverse:        *reverse,
+		indexOffset:    *indexOffset,
+		width:          *width,
+		height:         *height,
+		maxDimension:   *maxDimension,
+		trim:           *trim && !*trimUniform,
+		trimUniform:    *trimUniform,
+		flipH:          *flipH,
+		flipV:          *flipV,
+		grayscale:      *grayscale,
+		brightness:     *brightness,
+		contrast:       *contrast,
+		invert:         *invert,
+		timingFile:     *timingFile,
+		timingCSV:      *timingCSV,
+		exportPalette:  *exportPalette,
+		metadata:       *metadata,
+		readExtensions: *readExtensions,
+		embedMetadata:  *embedMetadata,
+		spriteSheet:    *spriteSheet,
+		columns:        *columns,
+		dataURI:        *dataURI,
+		html:           *html,
+		montage:        *montage,
+		diff:           *diff,
+		montageColumns: *montageColumns,
+		montagePadding: *montagePadding,
+		montageLabel:   *montageLabel,
+		lowMemory:      *lowMemory,
+		maxFrames:      *maxFrames,
+		truncateFrames: *truncateFrames,
+		failFast:       *failFast,
+		workers:        *workers,
+		fileWorkers:    *fileWorkers,
+		noClobber:      *noClobber,
+		resume:         *resume,
+		dryRun:         *dryRun,
+		quiet:          *quiet,
+		recursive:      *recursive,
+		verbose:        *verbose,
+	}
+
+	// 单帧模式下 -output 带有图像扩展名且不是已存在的目录时直接写入该文件，
+	// 未指定 -format 时按扩展名确定格式
+	if *thumbnail && setFlags["frame"] {
+		log.Fatal("-thumbnail cannot be combined with -frame")
+	}
+	// 最终图像即最后一帧叠加后的画布，其之前的所有帧都按处置方法叠加
+	if *flattenFinal {
+		if *thumbnail || setFlags["frame"] {
+			log.Fatal("-flatten-final cannot be combined with -frame or -thumbnail")
+		}
+		opts.frameIndex = -1
+	}
+	if *outputDir == "-" && !opts.singleFrame {
+		log.Fatal("-output - requires -frame, -thumbnail or -flatten-final")
+	}
+	if *at != "" {
+		if opts.singleFrame || setFlags["start"] || setFlags["end"] || setFlags["step"] || setFlags["every"] {
+			log.Fatal("-at cannot be combined with -frame, -thumbnail, -flatten-final, -start, -end, -step or -every")
+		}
+		times, err := parseTimestamps(*at)
+		if err != nil {
+			log.Fatalf("Invalid -at: %v", err)
+		}
+		opts.at = times
+	}
+	if opts.singleFrame {
+		if setFlags["start"] || setFlags["end"] || setFlags["step"] || setFlags["every"] {
+			log.Fatal("-frame, -thumbnail and -flatten-final cannot be combined with -start, -end, -step or -every")
+		}
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(*outputDir)), ".")
+		info, statErr := os.Stat(*outputDir)
+		if *outputDir == "-" {
+			// 标准输出只写入图像数据，其他信息一律不输出
+			if *verbose || *metadata || *dryRun {
+				log.Fatal("-output - cannot be combined with -verbose, -metadata or -dry-run")
+			}
+			opts.outputFile = "-"
+			opts.outputDir = "."
+			opts.quiet = true
+		} else if _, err := parseFormat(ext); err == nil && (statErr != nil || !info.IsDir()) {
+			opts.outputFile = *outputDir
+			opts.outputDir = filepath.Dir(*outputDir)
+			if !setFlags["format"] {
+				*format = ext
+			}
+		}
+	}
+
+	// 确定输出格式，逗号分隔的多种格式在同一次叠加中分别写出，其余检查以第一种格式为准
+	var formats []OutputFormat
+	for _, name := range strings.Split(*format, ",") {
+		f, err := parseFormat(strings.TrimSpace(name))
+		if err != nil {
+			log.Fatalf("Unsupported format: %s", name)
+		}
+		if !slices.Contains(formats, f) {
+			formats = append(formats, f)
+		}
+	}
+	// AVIF 编码器依赖 cgo 与 libaom，默认构建不包含
+	if slices.Contains(formats, FormatAVIF) && !gifconv.AVIFSupported {
+		log.Fatal("-format avif requires a build with -tags avif (and libaom installed)")
+	}
+	outputFormat := formats[0]
+	opts.format = outputFormat
+	if len(formats) > 1 {
+		for _, f := range formats {
+			if f.animated() || f == FormatICO || f == FormatPDF {
+				log.Fatal("Animated formats, ico and pdf cannot be combined with other formats")
+			}
+		}
+		if opts.singleFrame || *spriteSheet || *montage || *multipage || *zipPath != "" || *dataURI != "" {
+			log.Fatal("Multiple formats require per-frame output and cannot be combined with -frame, -thumbnail, -spritesheet, -montage, -multipage, -zip or -data-uri")
+		}
+	}
+
+	// 验证质量参数（JPG 与有损 WebP）
+	lossy := slices.Contains(formats, FormatJPG) || slices.Contains(formats, FormatPDF) || ((slices.Contains(formats, FormatWebP) || slices.Contains(formats, FormatAWebP) || slices.Contains(formats, FormatAVIF)) && !*lossless)
+	if lossy && (*quality < 1 || *quality > 100) {
 		log.Fatal("Quality must be between 1 and 100")
 	}
 
-	// 打开 GIF 文件
-	file, err := os.Open(*inputFile)
+	// 验证文件名模板
+	var err error
+	opts.nameTemplate, err = parseNameTemplate(*nameTmpl)
 	if err != nil {
-		log.Fatalf("Error opening GIF file: %v", err)
+		log.Fatalf("Invalid name template: %v", err)
+	}
+	if *pad < 0 || *indexOffset < 0 {
+		log.Fatal("-pad and -index-offset must not be negative")
+	}
+	if *readExtensions && !*metadata {
+		log.Fatal("-read-extensions requires -metadata")
+	}
+	if setFlags["dedupe-ssim"] && (math.IsNaN(*dedupeSSIM) || *dedupeSSIM <= 0 || *dedupeSSIM >= 1) {
+		log.Fatal("-dedupe-ssim must be between 0 and 1 (exclusive)")
+	}
+	if *maxFrames < 0 {
+		log.Fatal("-max-frames must not be negative")
+	}
+	if *truncateFrames && *maxFrames == 0 {
+		log.Fatal("-truncate-frames requires -max-frames")
+	}
+	if setFlags["name-template"] {
+		if setFlags["pad"] {
+			log.Fatal("-pad applies only to the default name template; use {index:0Nd} in -name-template")
+		}
+	} else {
+		opts.nameTemplate.autoPad(*pad)
 	}
-	defer file.Close()
 
-	// 解码 GIF
-	gifImg, err := gif.DecodeAll(file)
+	// 验证缩放参数
+	if *width < 0 || *height < 0 || *maxDimension < 0 {
+		log.Fatal("Width, height and max dimension must not be negative")
+	}
+	if *fitSize != "" {
+		if *width != 0 || *height != 0 || *maxDimension != 0 {
+			log.Fatal("-fit-size cannot be combined with -width, -height or -max-dimension")
+		}
+		opts.fitSize, err = gifconv.ParseSize(*fitSize)
+		if err != nil {
+			log.Fatalf("Invalid fit size: %v", err)
+		}
+	}
+	opts.filter, err = gifconv.ParseResizeFilter(*resizeFilter)
 	if err != nil {
-		log.Fatalf("Error decoding GIF: %v", err)
+		log.Fatalf("Unsupported resize filter: %s", *resizeFilter)
+	}
+
+	// 解析裁剪区域
+	if *crop != "" {
+		opts.crop, err = gifconv.ParseCropRect(*crop)
+		if err != nil {
+			log.Fatalf("Invalid crop: %v", err)
+		}
+	}
+
+	// 逐帧裁剪后各帧尺寸不同，需要统一尺寸的输出只能使用 -trim-uniform
+	if *trim && !*trimUniform && (*spriteSheet || *montage || outputFormat.animated()) {
+		log.Fatal("-trim cannot be combined with -spritesheet, -montage or an animated format; use -trim-uniform")
 	}
 
-	// 创建输出目录
-	err = os.MkdirAll(*outputDir, 0755)
+	// 验证旋转角度
+	opts.rotate, err = gifconv.NormalizeRotation(*rotate)
 	if err != nil {
-		log.Fatalf("Error creating output directory: %v", err)
+		log.Fatalf("Invalid rotation: %v", err)
+	}
+	if setFlags["rotate-deg"] {
+		if math.IsNaN(*rotateDeg) || math.IsInf(*rotateDeg, 0) {
+			log.Fatal("-rotate-deg must be a finite angle")
+		}
+		if *rotate != 0 {
+			log.Fatal("-rotate and -rotate-deg cannot be combined")
+		}
+		opts.rotateDeg = *rotateDeg
 	}
 
-	// 获取输入文件的基本名称（不含扩展名）
-	baseFileName := filepath.Base(*inputFile)
-	baseFileName = baseFileName[:len(baseFileName)-len(filepath.Ext(baseFileName))]
+	// 验证亮度、对比度与伽马
+	if math.IsNaN(*brightness) || *brightness < -1 || *brightness > 1 {
+		log.Fatal("-brightness must be between -1 and 1")
+	}
+	if math.IsNaN(*contrast) || math.IsInf(*contrast, 0) || *contrast < 0 {
+		log.Fatal("-contrast must not be negative")
+	}
 
-	// 处理每一帧
-	for i := 0; i < len(gifImg.Image); i++ {
-		// 生成完整帧图像
-		frameImg := gifFrameToImage(gifImg, i)
+	// 伽马查找表只构建一次，所有帧共用
+	if *gamma != 1 {
+		if math.IsNaN(*gamma) || math.IsInf(*gamma, 0) || *gamma <= 0 {
+			log.Fatal("-gamma must be a positive number")
+		}
+		opts.gamma = gifconv.NewGammaTable(*gamma)
+	}
 
-		// 创建输出文件名
-		ext := ".png"
-		if outputFormat == FormatJPG {
-			ext = ".jpg"
+	// 解析背景色，仅用于 JPG 与 -flatten，其他格式默认保留透明通道
+	var bg color.Color
+	if *background != "" {
+		c, err := gifconv.ParseHexColor(*background)
+		if err != nil {
+			log.Fatalf("Invalid background color: %s", *background)
 		}
-		outFileName := fmt.Sprintf("%s_frame_%03d%s", baseFileName, i, ext)
-		outPath := filepath.Join(*outputDir, outFileName)
+		bg = c
+	}
+	opts.fitBackground = bg
 
-		// 创建输出文件
-		outFile, err := os.Create(outPath)
+	// 加载背景图像，所有帧共用
+	var bgImage image.Image
+	bgFit, err := gifconv.ParseBackgroundFit(*backgroundFit)
+	if err != nil {
+		log.Fatalf("Unsupported background fit: %s", *backgroundFit)
+	}
+	if *backgroundImage != "" {
+		if bgImage, err = decodeImageFile(*backgroundImage); err != nil {
+			log.Fatalf("Error loading background image: %v", err)
+		}
+	} else if setFlags["background-fit"] {
+		log.Fatal("-background-fit requires -background-image")
+	}
+
+	// 加载水印图像，所有帧共用
+	opts.watermarkPos, err = gifconv.ParseWatermarkPosition(*watermarkPos)
+	if err != nil {
+		log.Fatalf("Unsupported watermark position: %s", *watermarkPos)
+	}
+	if math.IsNaN(*watermarkOpacity) || *watermarkOpacity < 0 || *watermarkOpacity > 1 {
+		log.Fatal("-watermark-opacity must be between 0 and 1")
+	}
+	opts.watermarkAlpha = *watermarkOpacity
+	if *watermark != "" {
+		if opts.watermark, err = decodeImageFile(*watermark); err != nil {
+			log.Fatalf("Error loading watermark: %v", err)
+		}
+	} else if setFlags["watermark-pos"] || setFlags["watermark-opacity"] {
+		log.Fatal("-watermark-pos and -watermark-opacity require -watermark")
+	}
+
+	// 验证 JPEG 参数
+	jpegSubsampling, err := gifconv.ParseJPEGSubsampling(*jpegSubsample)
+	if err != nil {
+		log.Fatalf("Unsupported JPEG subsampling: %s", *jpegSubsample)
+	}
+	if *jpegCMYK && (!slices.Contains(formats, FormatJPG) || setFlags["jpeg-subsample"]) {
+		log.Fatal("-jpeg-cmyk requires -format jpg and cannot be combined with -jpeg-subsample")
+	}
+	if setFlags["jpeg-subsample"] && !slices.Contains(formats, FormatJPG) {
+		log.Fatal("-jpeg-subsample requires -format jpg")
+	}
+
+	// 验证 PNG 参数
+	pngLevel, err := parsePNGCompression(*pngCompression)
+	if err != nil {
+		log.Fatalf("Unsupported PNG compression: %s", *pngCompression)
+	}
+
+	if *paletted && !slices.Contains(formats, FormatPNG) {
+		log.Fatal("-paletted requires -format png")
+	}
+	if setFlags["colors"] && (*colors < 2 || *colors > 256) {
+		log.Fatal("-colors must be between 2 and 256")
+	}
+	if *colors > 0 && !slices.Contains(formats, FormatPNG) {
+		log.Fatal("-colors requires -format png")
+	}
+	if *dither && *colors == 0 {
+		log.Fatal("-dither requires -colors")
+	}
+	if *progressive && (outputFormat != FormatPNG || len(formats) > 1) {
+		log.Fatal("-progressive requires -format png and cannot be combined with other formats")
+	}
+	if *embedMetadata {
+		if slices.ContainsFunc(formats, func(f OutputFormat) bool { return f != FormatPNG && f != FormatJPG }) {
+			log.Fatal("-embed-metadata requires -format png or jpg")
+		}
+		if *spriteSheet || *montage || *dataURI != "" {
+			log.Fatal("-embed-metadata cannot be combined with -spritesheet, -montage or -data-uri")
+		}
+	}
+
+	// 验证 TIFF 参数
+	opts.tiffCompression, err = gifconv.ParseTIFFCompression(*tiffCompression)
+	if err != nil {
+		log.Fatalf("Unsupported TIFF compression: %s", *tiffCompression)
+	}
+	// 验证 ICO 参数
+	sizes, err := gifconv.ParseICOSizes(*icoSizes)
+	if err != nil {
+		log.Fatalf("Invalid -ico-sizes: %v", err)
+	}
+	if setFlags["ico-sizes"] && outputFormat != FormatICO {
+		log.Fatal("-ico-sizes requires -format ico")
+	}
+
+	// PDF 总是将所有帧写入同一个文档，与多页 TIFF 走同一流程
+	if outputFormat == FormatPDF {
+		*multipage = true
+		opts.multipage = true
+	}
+	if *multipage && outputFormat != FormatTIFF && outputFormat != FormatPDF {
+		log.Fatal("-multipage requires -format tiff or pdf")
+	}
+	if *multipage && *spriteSheet {
+		log.Fatal("-multipage and -format pdf cannot be combined with -spritesheet")
+	}
+
+	// 只有动画格式带有时序，-timing-file 随之写出重新采样后的时序
+	if setFlags["fps"] {
+		if *fps <= 0 || math.IsInf(*fps, 0) || math.IsNaN(*fps) {
+			log.Fatal("-fps must be a positive number")
+		}
+		if !outputFormat.animated() {
+			log.Fatal("-fps requires -format apng or awebp")
+		}
+		if *timingCSV != "" {
+			log.Fatal("-timing-csv describes the source frames and cannot be combined with -fps (use -timing-file)")
+		}
+	}
+
+	if *spriteSheet && outputFormat.animated() {
+		log.Fatal("Sprite sheets cannot be written as APNG or animated WebP")
+	}
+
+	// 预览图只能是单张静态图像
+	if *montage && (*spriteSheet || *multipage || outputFormat.animated()) {
+		log.Fatal("-montage cannot be combined with -spritesheet, -multipage or an animated format")
+	}
+	if *montageColumns < 0 || *montagePadding < 0 {
+		log.Fatal("Montage columns and padding must not be negative")
+	}
+
+	// 数据 URI 与逐帧输出一样每帧一项，只是写入同一个文件
+	if *dataURI != "" {
+		if *dataURI != "lines" && *dataURI != "json" {
+			log.Fatalf("Unsupported -data-uri layout: %s (want lines or json)", *dataURI)
+		}
+		if opts.singleFrame || *spriteSheet || *montage || *multipage || *zipPath != "" || outputFormat.animated() {
+			log.Fatal("-data-uri cannot be combined with -frame, -thumbnail, -spritesheet, -montage, -multipage, -zip or an animated format")
+		}
+	}
+
+	// 预览页引用逐帧输出的文件
+	if *html && (opts.singleFrame || *spriteSheet || *montage || *multipage || *zipPath != "" || *dataURI != "" || outputFormat.animated()) {
+		log.Fatal("-html requires per-frame output and cannot be combined with -frame, -thumbnail, -spritesheet, -montage, -multipage, -zip, -data-uri or an animated format")
+	}
+
+	// 差异图像与逐帧输出一样每帧一个文件
+	if *diff && (opts.singleFrame || *spriteSheet || *montage || *multipage || *zipPath != "" || *dataURI != "" || *html || outputFormat.animated() || len(formats) > 1) {
+		log.Fatal("-diff cannot be combined with -frame, -thumbnail, -spritesheet, -montage, -multipage, -zip, -data-uri, -html, an animated format or multiple formats")
+	}
+
+	// 验证输出比例，各比例都由同一帧直接缩放而来
+	if *outputSizes != "" {
+		opts.scales, err = parseScales(*outputSizes)
 		if err != nil {
-			log.Printf("Error creating output file %s: %v", outFileName, err)
-			continue
+			log.Fatal(err)
+		}
+		if opts.singleFrame || *spriteSheet || *montage || *multipage || *zipPath != "" || *dataURI != "" || *diff || outputFormat.animated() {
+			log.Fatal("-sizes requires per-frame output and cannot be combined with -frame, -thumbnail, -spritesheet, -montage, -multipage, -zip, -data-uri, -diff or an animated format")
+		}
+	}
+
+	// 压缩包只容纳逐帧输出
+	if *zipPath != "" && (opts.singleFrame || *spriteSheet || *montage || *multipage || outputFormat.animated()) {
+		log.Fatal("-zip cannot be combined with -frame, -thumbnail, -spritesheet, -montage, -multipage or an animated format")
+	}
+
+	if *quiet && *verbose {
+		log.Fatal("-quiet and -verbose cannot be combined")
+	}
+
+	if *workers < 1 || *fileWorkers < 1 {
+		log.Fatal("Workers must be at least 1")
+	}
+
+	// 编码、背景与缩放交给 gifconv.Converter；-flatten 或 JPG 指定背景色时才合成背景，
+	// PDF 页面不含透明通道，总是合成到背景色上（默认白色）
+	newConverter := func(f OutputFormat) *gifconv.Converter {
+		converterOpts := []gifconv.ConverterOption{
+			gifconv.WithFormat(f.stillFormat()),
+			gifconv.WithQuality(*quality),
+			gifconv.WithResize(*width, *height, opts.filter),
+			gifconv.WithWorkers(*workers),
+			gifconv.WithLossless(*lossless),
+			gifconv.WithJPEGSubsampling(jpegSubsampling),
+			gifconv.WithJPEGCMYK(*jpegCMYK && f == FormatJPG),
+			gifconv.WithPNGCompression(pngLevel),
+			gifconv.WithPaletted(*paletted),
+			gifconv.WithColors(*colors, *dither),
+			gifconv.WithInterlaced(*progressive),
+			gifconv.WithTIFFCompression(opts.tiffCompression),
+			gifconv.WithICOSizes(sizes),
 		}
+		if *flatten || f == FormatPDF || (bg != nil && f == FormatJPG) {
+			c := bg
+			if c == nil {
+				c = color.White
+			}
+			converterOpts = append(converterOpts, gifconv.WithBackground(c))
+		}
+		if bgImage != nil {
+			converterOpts = append(converterOpts, gifconv.WithBackgroundImage(bgImage, bgFit))
+		}
+		return gifconv.NewConverter(converterOpts...)
+	}
+	opts.converter = newConverter(outputFormat)
+	for _, f := range formats[1:] {
+		opts.extraFormats = append(opts.extraFormats, formatOutput{format: f, converter: newConverter(f)})
+	}
+
+	// 合并模式只写出逐帧图像，各输入单独输出的附带文件与合并输出无关
+	if *combine {
+		if *combineLayout != combineHorizontal && *combineLayout != combineGrid {
+			log.Fatalf("Unsupported combine layout: %s", *combineLayout)
+		}
+		if opts.singleFrame || *at != "" || *spriteSheet || *montage || *multipage || *zipPath != "" || *dataURI != "" || *diff || *html ||
+			*resume || *trimUniform || *embedMetadata || *metadata || *timingFile != "" || *timingCSV != "" || *dryRun || outputFormat.animated() {
+			log.Fatal("-combine writes per-frame images and cannot be combined with -frame, -thumbnail, -at, -spritesheet, -montage, -multipage, -zip, -data-uri, -diff, -html, -resume, -trim-uniform, -embed-metadata, -metadata, -timing-file, -timing-csv, -dry-run or an animated format")
+		}
+	} else if setFlags["combine-layout"] {
+		log.Fatal("-combine-layout requires -combine")
+	}
+
+	// 展开目录输入
+	conversions, err := expandInputs(inputs, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(conversions) == 0 {
+		log.Fatal("No GIF files found")
+	}
+
+	if *combine && len(conversions) < 2 {
+		log.Fatal("-combine requires at least two inputs")
+	}
 
-		// 根据格式保存文件
-		switch outputFormat {
-		case FormatPNG:
-			err = png.Encode(outFile, frameImg)
-		case FormatJPG:
-			err = jpeg.Encode(outFile, frameImg, &jpeg.Options{Quality: *quality})
+	// 多个输入共用输出目录，单一文件名的选项会互相覆盖
+	if !*combine && len(conversions) > 1 && (*baseName != "" || *timingFile != "" || *timingCSV != "" || *exportPalette != "" || *zipPath != "" || *html || opts.outputFile != "") {
+		log.Fatal("-basename, -timing-file, -timing-csv, -export-palette, -zip, -html and a file -output cannot be used with multiple inputs")
+	}
+
+	// 合并模式：所有输入写入同一组帧，未指定 -output 时写入 ./combined/
+	if *combine {
+		paths := make([]string, len(conversions))
+		for n, c := range conversions {
+			paths[n] = c.input
+		}
+		outputDir := opts.outputDir
+		if opts.autoOutputDir {
+			outputDir = filepath.Join(outputDir, "combined")
+		}
+		if err := combineGIFs(ctx, paths, outputDir, opts); err != nil {
+			log.Printf("Error combining GIFs: %v", err)
+			if sig := interrupted(); sig != nil {
+				os.Exit(signalExitCode(sig))
+			}
+			os.Exit(1)
 		}
+		return
+	}
 
+	// 性能分析：写出 pprof 数据，转换结束后打印各阶段耗时
+	var stopProfiles func() error
+	if *profile {
+		opts.timer = newStageTimer()
+		var err error
+		stopProfiles, err = startProfiles(*cpuProfile, *memProfile)
 		if err != nil {
-			log.Printf("Error encoding frame %d: %v", i, err)
-			outFile.Close()
-			continue
+			log.Fatal(err)
 		}
+	} else if setFlags["cpu-profile"] || setFlags["mem-profile"] {
+		log.Fatal("-cpu-profile and -mem-profile require -profile")
+	}
 
-		outFile.Close()
-		fmt.Printf("Saved frame %d as %s\n", i, outFileName)
+	// 转换所有输入文件，单个文件失败不影响其余文件
+	results := convertAll(ctx, conversions, opts)
+	if stopProfiles != nil {
+		if err := stopProfiles(); err != nil {
+			log.Printf("Error writing profile: %v", err)
+		}
+		opts.timer.print()
+	}
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+		}
 	}
 
-	fmt.Printf("Successfully converted GIF to %d image files\n", len(gifImg.Image))
+	if len(conversions) > 1 {
+		printSummary(results)
+		fmt.Printf("Converted %d of %d files\n", len(conversions)-failed, len(conversions))
+	}
+	if sig := interrupted(); sig != nil {
+		os.Exit(signalExitCode(sig))
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
 }
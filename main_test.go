@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 设置该环境变量时测试二进制直接作为命令行程序运行，供端到端测试调用
+const runMainEnv = "GIF2PNG_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) == "1" {
+		os.Args = append([]string{"gif2png"}, os.Args[1:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// 以 args 运行命令行程序，返回标准输出
+func runMain(t *testing.T, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("gif2png %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return stdout.String()
+}
+
+// 写出一个 n 帧的测试 GIF，每帧颜色不同
+func writeTestGIF(t *testing.T, path string, n int) {
+	t.Helper()
+	palette := color.Palette{color.RGBA{0, 0, 0, 0}}
+	for i := range n {
+		palette = append(palette, color.RGBA{uint8(i * 255 / n), 0x80, uint8(255 - i*255/n), 0xff})
+	}
+	g := &gif.GIF{Config: image.Config{ColorModel: palette, Width: 16, Height: 8}}
+	for i := range n {
+		m := image.NewPaletted(image.Rect(0, 0, 16, 8), palette)
+		for p := range m.Pix {
+			m.Pix[p] = uint8(1 + i)
+		}
+		g.Image = append(g.Image, m)
+		g.Delay = append(g.Delay, 10)
+		g.Disposal = append(g.Disposal, gif.DisposalNone)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, g); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConvertWorkers(t *testing.T) {
+	// 并行编码的输出文件与日志顺序应与单个编码协程时完全一致
+	const frames = 12
+	dir := t.TempDir()
+	input := filepath.Join(dir, "anim.gif")
+	writeTestGIF(t, input, frames)
+
+	serialDir, parallelDir := filepath.Join(dir, "serial"), filepath.Join(dir, "parallel")
+	runMain(t, "-workers", "1", "-output", serialDir, input)
+	stdout := runMain(t, "-workers", "4", "-output", parallelDir, input)
+
+	var saved []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if strings.HasPrefix(line, "Saved frame ") {
+			saved = append(saved, line)
+		}
+	}
+	if len(saved) != frames {
+		t.Fatalf("got %d saved lines, want %d:\n%s", len(saved), frames, stdout)
+	}
+	for i, line := range saved {
+		name := fmt.Sprintf("anim_frame_%03d.png", i)
+		if want := fmt.Sprintf("Saved frame %d as %s", i, name); line != want {
+			t.Errorf("line %d: got %q, want %q", i, line, want)
+		}
+		want, err := os.ReadFile(filepath.Join(serialDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join(parallelDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs between -workers 1 and -workers 4", name)
+		}
+	}
+}
+
+func TestUsageHidesProfileFlags(t *testing.T) {
+	// 未指定输入与 -h 打印相同的用法，都不列出 hiddenFlags 中的参数
+	for _, args := range [][]string{nil, {"-h"}} {
+		cmd := exec.Command(os.Args[0], args...)
+		cmd.Env = append(os.Environ(), runMainEnv+"=1")
+		out, _ := cmd.CombinedOutput()
+		usage := string(out)
+		if !strings.HasPrefix(usage, "Usage: gifconvert ") {
+			t.Errorf("gif2png %v: usage starts with %q", args, strings.SplitN(usage, "\n", 2)[0])
+		}
+		for name := range hiddenFlags {
+			if strings.Contains(usage, "\n  -"+name+" ") || strings.Contains(usage, "\n  -"+name+"\n") {
+				t.Errorf("gif2png %v: usage lists hidden flag -%s", args, name)
+			}
+		}
+		if !strings.Contains(usage, "\n  -output ") {
+			t.Errorf("gif2png %v: usage does not list -output", args)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"image/gif"
+	"os"
+	"unicode/utf8"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// GIF 元数据，写入 <base>_meta.json。
+// loop_count 沿用 image/gif 的语义，play_count 为总播放次数，0 表示无限循环
+type gifMetadata struct {
+	FrameCount int             `json:"frame_count"`
+	Width      int             `json:"width"`
+	Height     int             `json:"height"`
+	LoopCount  int             `json:"loop_count"`
+	PlayCount  int             `json:"play_count"`
+	Frames     []frameMetadata `json:"frames"`
+	// 仅 -read-extensions 时写出
+	Extensions []extensionMetadata `json:"extensions,omitempty"`
+}
+
+// 单帧元数据，延迟单位为 1/100 秒
+type frameMetadata struct {
+	Index    int    `json:"index"`
+	Delay    int    `json:"delay"`
+	Disposal string `json:"disposal"`
+}
+
+// 注释扩展或应用扩展块，frame 为其后第一帧的序号，size 为数据字节数。
+// 注释与 XMP 数据包以文本写出，其他应用数据只记录标识与大小
+type extensionMetadata struct {
+	Frame int    `json:"frame"`
+	Type  string `json:"type"`
+	AppID string `json:"app_id,omitempty"`
+	Size  int    `json:"size"`
+	Text  string `json:"text,omitempty"`
+}
+
+func newExtensionMetadata(ext gifconv.Extension) extensionMetadata {
+	meta := extensionMetadata{Frame: ext.Frame, Type: "application", AppID: ext.AppID, Size: len(ext.Data)}
+	if ext.Comment {
+		meta.Type = "comment"
+	}
+	if (ext.Comment || ext.AppID == "XMP DataXMP") && utf8.Valid(ext.Data) {
+		meta.Text = string(ext.Data)
+	}
+	return meta
+}
+
+// 从解码后的 GIF 中收集元数据
+func newGIFMetadata(g *gif.GIF) gifMetadata {
+	bounds := gifconv.CanvasBounds(g)
+	meta := gifMetadata{
+		FrameCount: len(g.Image),
+		Width:      bounds.Dx(),
+		Height:     bounds.Dy(),
+		LoopCount:  g.LoopCount,
+		PlayCount:  gifconv.PlayCount(g.LoopCount),
+		Frames:     make([]frameMetadata, len(g.Image)),
+	}
+	for i := range g.Image {
+		frame := frameMetadata{Index: i, Disposal: gifconv.DisposalName(0)}
+		if i < len(g.Delay) {
+			frame.Delay = g.Delay[i]
+		}
+		if i < len(g.Disposal) {
+			frame.Disposal = gifconv.DisposalName(g.Disposal[i])
+		}
+		meta.Frames[i] = frame
+	}
+	return meta
+}
+
+// 将元数据以 JSON 格式写入文件，包含 extensions 中的扩展块，各帧的序号加上 offset（-index-offset）
+func writeMetadataFile(path string, g *gif.GIF, extensions []gifconv.Extension, offset int) error {
+	meta := newGIFMetadata(g)
+	for i := range meta.Frames {
+		meta.Frames[i].Index += offset
+	}
+	for _, ext := range extensions {
+		extMeta := newExtensionMetadata(ext)
+		extMeta.Frame += offset
+		meta.Extensions = append(meta.Extensions, extMeta)
+	}
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// 帧序号标签使用的字体及标签行高度
+var (
+	labelFace   = basicfont.Face7x13
+	labelHeight = labelFace.Height + 2
+)
+
+// 将各帧排列成白底的预览网格，columns 不大于 0 时按帧数取近似正方形的列数，
+// padding 为单元格之间及四周的间距，label 为 true 时在每帧下方标注帧序号
+func buildMontage(frames []image.Image, indices []int, columns, padding int, label bool) *image.RGBA {
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(frames)))))
+	}
+	columns = min(columns, len(frames))
+	rows := (len(frames) + columns - 1) / columns
+
+	// 单元格大小取所有帧的最大尺寸，标签占用单元格下方的一行
+	var cell image.Point
+	for _, frame := range frames {
+		size := frame.Bounds().Size()
+		cell.X = max(cell.X, size.X)
+		cell.Y = max(cell.Y, size.Y)
+	}
+	cellHeight := cell.Y
+	if label {
+		cellHeight += labelHeight
+	}
+
+	montage := image.NewRGBA(image.Rect(0, 0, padding+columns*(cell.X+padding), padding+rows*(cellHeight+padding)))
+	draw.Draw(montage, montage.Bounds(), image.White, image.Point{}, draw.Src)
+	for n, frame := range frames {
+		bounds := frame.Bounds()
+		at := image.Pt(padding+(n%columns)*(cell.X+padding), padding+(n/columns)*(cellHeight+padding))
+		draw.Draw(montage, bounds.Sub(bounds.Min).Add(at), frame, bounds.Min, draw.Over)
+		if label {
+			drawLabel(montage, strconv.Itoa(indices[n]), image.Pt(at.X, at.Y+cell.Y))
+		}
+	}
+	return montage
+}
+
+// 在 at 处开始的标签行内绘制黑色文字
+func drawLabel(dst draw.Image, text string, at image.Point) {
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(color.Black),
+		Face: labelFace,
+		Dot:  fixed.P(at.X, at.Y+1+labelFace.Ascent),
+	}
+	d.DrawString(text)
+}
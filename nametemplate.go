@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 默认的逐帧输出文件名模板
+const defaultNameTemplate = "{base}_frame_{index:03d}{ext}"
+
+// 匹配 {name} 或 {name:0Nd} 形式的标记
+var templateToken = regexp.MustCompile(`^\{([a-z]+)(?::(0?[1-9][0-9]*)d)?\}`)
+
+// 逐帧输出文件名模板，支持 {base}、{index}、{ext}、{delay}，
+// {index} 与 {delay} 可带宽度，如 {index:04d}
+type nameTemplate struct {
+	parts []templatePart
+	// 默认模板的 {index} 补零宽度，为 0 时由 forFrameCount 按总帧数确定
+	pad int
+}
+
+// 模板片段：token 为空时是原样输出的文本；autoPad 的 {index} 宽度取自模板的 pad
+type templatePart struct {
+	text    string
+	token   string
+	width   string
+	autoPad bool
+}
+
+// 解析并验证文件名模板，未知标记、括号不匹配或缺少 {index} 时返回错误
+func parseNameTemplate(s string) (*nameTemplate, error) {
+	if strings.ContainsAny(s, `/\`) {
+		return nil, fmt.Errorf("name template %q must not contain path separators", s)
+	}
+	t := &nameTemplate{}
+	hasIndex := false
+	for rest := s; rest != ""; {
+		switch {
+		case rest[0] == '{':
+			m := templateToken.FindStringSubmatch(rest)
+			if m == nil {
+				return nil, fmt.Errorf("name template %q has a malformed token at %q", s, rest)
+			}
+			switch m[1] {
+			case "index", "delay":
+			case "base", "ext":
+				if m[2] != "" {
+					return nil, fmt.Errorf("name template %q: {%s} does not take a width", s, m[1])
+				}
+			default:
+				return nil, fmt.Errorf("name template %q has unknown token {%s}", s, m[1])
+			}
+			hasIndex = hasIndex || m[1] == "index"
+			t.parts = append(t.parts, templatePart{token: m[1], width: m[2]})
+			rest = rest[len(m[0]):]
+		case rest[0] == '}':
+			return nil, fmt.Errorf("name template %q has an unmatched }", s)
+		default:
+			n := strings.IndexAny(rest, "{}")
+			if n < 0 {
+				n = len(rest)
+			}
+			t.parts = append(t.parts, templatePart{text: rest[:n]})
+			rest = rest[n:]
+		}
+	}
+	if !hasIndex {
+		return nil, fmt.Errorf("name template %q must contain {index} so frames do not overwrite each other", s)
+	}
+	return t, nil
+}
+
+// 使模板中的 {index} 按 pad 位补零，pad 为 0 时由各输入的总帧数决定，用于默认模板
+func (t *nameTemplate) autoPad(pad int) {
+	t.pad = pad
+	for i := range t.parts {
+		if t.parts[i].token == "index" {
+			t.parts[i].autoPad = true
+			t.parts[i].width = "03"
+			if pad > 0 {
+				t.parts[i].width = fmt.Sprintf("0%d", pad)
+			}
+		}
+	}
+}
+
+// 返回按 count 帧确定补零宽度的模板：宽度至少为 3 位，并能容纳最大帧序号，
+// 使按文件名排序即为帧顺序。没有需要自动补零的 {index} 或已指定宽度时返回 t 本身
+func (t *nameTemplate) forFrameCount(count int) *nameTemplate {
+	width := fmt.Sprintf("0%d", max(3, len(strconv.Itoa(count-1))))
+	if t.pad != 0 || !slices.ContainsFunc(t.parts, func(p templatePart) bool { return p.autoPad && p.width != width }) {
+		return t
+	}
+	c := &nameTemplate{parts: slices.Clone(t.parts), pad: t.pad}
+	for i := range c.parts {
+		if c.parts[i].autoPad {
+			c.parts[i].width = width
+		}
+	}
+	return c
+}
+
+// 返回单帧静态 GIF 使用的模板 {base}{ext}，文件名不带帧序号。
+// 只替换默认模板，自定义模板返回 t 本身
+func (t *nameTemplate) forStaticImage() *nameTemplate {
+	if !slices.ContainsFunc(t.parts, func(p templatePart) bool { return p.autoPad }) {
+		return t
+	}
+	return &nameTemplate{parts: []templatePart{{token: "base"}, {token: "ext"}}}
+}
+
+// 生成单帧的输出文件名，ext 含前导点，delay 单位为 1/100 秒
+func (t *nameTemplate) render(base string, index int, ext string, delay int) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		switch p.token {
+		case "":
+			b.WriteString(p.text)
+		case "base":
+			b.WriteString(base)
+		case "ext":
+			b.WriteString(ext)
+		case "index":
+			fmt.Fprintf(&b, "%"+p.width+"d", index)
+		case "delay":
+			fmt.Fprintf(&b, "%"+p.width+"d", delay)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"image/gif"
+	"os"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 返回 GIF 的源调色板：优先使用全局颜色表，没有时取第一帧的调色板（低内存模式下需先解码该帧）
+func sourcePalette(g *gif.GIF, decoder *gifconv.FrameDecoder) (color.Palette, error) {
+	if p, ok := g.Config.ColorModel.(color.Palette); ok && len(p) > 0 {
+		return p, nil
+	}
+	frame := g.Image[0]
+	if decoder != nil {
+		var err error
+		if frame, err = decoder.Frame(0); err != nil {
+			return nil, err
+		}
+	}
+	if len(frame.Palette) == 0 {
+		return nil, errors.New("GIF has no color table")
+	}
+	return frame.Palette, nil
+}
+
+// 将调色板写为 GIMP 调色板（.gpl）文件，Aseprite 等工具同样可以导入，
+// 每行为 0-255 的 R G B 与十六进制颜色名
+func writePaletteFile(path, name string, palette color.Palette) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "GIMP Palette\nName: %s\nColumns: 16\n#\n", name)
+	for _, c := range palette {
+		rgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+		fmt.Fprintf(w, "%3d %3d %3d\t#%02x%02x%02x\n", rgba.R, rgba.G, rgba.B, rgba.R, rgba.G, rgba.B)
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
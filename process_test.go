@@ -0,0 +1,31 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+func TestProcessInvertBeforeGrayscale(t *testing.T) {
+	// -invert 在 -grayscale 之前生效：Grayscale 丢弃透明通道并使用预乘颜色，
+	// 半透明像素先反相再转灰度得到 alpha 减亮度，顺序颠倒时会得到 255 减亮度
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, color.RGBA{40, 80, 120, 255})
+	src.SetRGBA(1, 0, color.RGBA{20, 40, 60, 128})
+	o := &options{invert: true, grayscale: true, contrast: 1, converter: gifconv.NewConverter()}
+
+	out := o.process(src)
+	gray, ok := out.(*image.Gray)
+	if !ok {
+		t.Fatalf("process returned %T, want *image.Gray", out)
+	}
+	want := gifconv.Grayscale(gifconv.Invert(src))
+	if string(gray.Pix) != string(want.Pix) {
+		t.Errorf("got %v, want %v", gray.Pix, want.Pix)
+	}
+	if reversed := gifconv.Invert(gifconv.Grayscale(src)); gray.Pix[1] == reversed.Pix[1] {
+		t.Fatalf("test pixel does not distinguish the order: %d", gray.Pix[1])
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// -profile 记录耗时的转换阶段
+type stage int
+
+const (
+	stageDecode stage = iota
+	stageComposite
+	stageProcess
+	stageEncode
+	stageWrite
+	stageCount
+)
+
+var stageNames = [stageCount]string{"decode", "composite", "process", "encode", "write"}
+
+// 不在 -help 中列出的调试参数
+var hiddenFlags = map[string]bool{"profile": true, "cpu-profile": true, "mem-profile": true}
+
+// 累计各阶段的耗时，编码协程并发累加，因此总和可能超过实际经过的时间。
+// 为 nil 时不计时
+type stageTimer struct {
+	start time.Time
+	total [stageCount]atomic.Int64
+}
+
+func newStageTimer() *stageTimer {
+	return &stageTimer{start: time.Now()}
+}
+
+// 将从 start 到现在的时间计入阶段 s，用法为 defer t.since(s, time.Now())
+func (t *stageTimer) since(s stage, start time.Time) {
+	if t != nil {
+		t.total[s].Add(int64(time.Since(start)))
+	}
+}
+
+// 打印各阶段的累计耗时与总耗时
+func (t *stageTimer) print() {
+	parts := make([]string, 0, stageCount)
+	for s, name := range stageNames {
+		parts = append(parts, fmt.Sprintf("%s %v", name, time.Duration(t.total[s].Load()).Round(time.Microsecond)))
+	}
+	fmt.Printf("Profile: %s (wall %v)\n", strings.Join(parts, ", "), time.Since(t.start).Round(time.Microsecond))
+}
+
+// 开始写出 CPU 分析数据，返回的函数停止 CPU 分析并写出内存分析数据
+func startProfiles(cpuPath, memPath string) (func() error, error) {
+	cpuFile, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, fmt.Errorf("creating CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(cpuFile); err != nil {
+		cpuFile.Close()
+		return nil, fmt.Errorf("starting CPU profile: %w", err)
+	}
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := cpuFile.Close(); err != nil {
+			return fmt.Errorf("writing CPU profile: %w", err)
+		}
+		memFile, err := os.Create(memPath)
+		if err != nil {
+			return fmt.Errorf("creating memory profile: %w", err)
+		}
+		// 先回收垃圾，使分析数据反映仍在使用的内存
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(memFile); err != nil {
+			memFile.Close()
+			return fmt.Errorf("writing memory profile: %w", err)
+		}
+		return memFile.Close()
+	}, nil
+}
+
+// 打印用法概要与参数列表，跳过 hiddenFlags 中的参数；-h 与未指定输入时共用
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: gifconvert -input <gif_file> [-output <output_directory|file>] [options] [more.gif ...]")
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+			visible.Lookup(f.Name).DefValue = f.DefValue
+		}
+	})
+	visible.PrintDefaults()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// 逐帧进度显示：标准输出为终端且一次只转换一个文件时在 stderr 上原地刷新 "frame n/total"，
+// 否则逐行打印每帧的保存信息；quiet 时不输出任何进度
+type progress struct {
+	mu      sync.Mutex
+	total   int
+	done    int
+	inPlace bool
+	quiet   bool
+	out     io.Writer // 逐帧信息的输出，默认为标准输出
+
+	// frameDoneAt 按序号排序输出：next 为下一条要打印的序号，pending 暂存先完成的后续信息
+	next    int
+	pending map[int]frameMessage
+}
+
+// 按顺序输出的一帧的信息：text 打印到标准输出，warning 写到日志
+type frameMessage struct {
+	text    string
+	warning string
+}
+
+func newProgress(total int, opts *options) *progress {
+	inPlace := opts.fileWorkers == 1 && isTerminal(os.Stdout)
+	return &progress{total: total, inPlace: inPlace, quiet: opts.quiet, out: os.Stdout}
+}
+
+// 判断文件是否为终端
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// 记录一帧处理完毕，msg 为逐行模式下打印的信息，为空则不打印
+func (p *progress) frameDone(msg string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	switch {
+	case p.quiet:
+	case p.inPlace:
+		fmt.Fprintf(os.Stderr, "\rframe %d/%d", p.done, p.total)
+	case msg != "":
+		fmt.Fprintln(p.out, msg)
+	}
+}
+
+// 记录序号为 seq（从 0 开始连续编号）的一项处理完毕。逐行模式下按序号顺序输出信息，
+// 之前的项尚未完成时先暂存，因此并行编码时日志顺序仍与帧顺序一致，不受完成先后影响
+func (p *progress) frameDoneAt(seq int, m frameMessage) {
+	if p.inPlace {
+		// 日志经由 Write 输出并重绘进度行，需在加锁之外调用
+		if m.warning != "" && !p.quiet {
+			log.Print(m.warning)
+		}
+		p.frameDone("")
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	if p.quiet {
+		return
+	}
+	if p.pending == nil {
+		p.pending = make(map[int]frameMessage)
+	}
+	p.pending[seq] = m
+	for {
+		m, ok := p.pending[p.next]
+		if !ok {
+			break
+		}
+		delete(p.pending, p.next)
+		p.next++
+		m.print(p.out)
+	}
+}
+
+func (m frameMessage) print(w io.Writer) {
+	if m.warning != "" {
+		log.Print(m.warning)
+	}
+	if m.text != "" {
+		fmt.Fprintln(w, m.text)
+	}
+}
+
+// Write 作为 log 的输出，原地刷新模式下先清除进度行，写出日志后再重绘进度
+func (p *progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inPlace || p.quiet || p.done == 0 {
+		return os.Stderr.Write(b)
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	n, err := os.Stderr.Write(b)
+	fmt.Fprintf(os.Stderr, "\rframe %d/%d", p.done, p.total)
+	return n, err
+}
+
+// 结束进度显示。中途停止时之前的某些项不会完成，按序号输出仍暂存的信息
+func (p *progress) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, seq := range slices.Sorted(maps.Keys(p.pending)) {
+		p.pending[seq].print(p.out)
+	}
+	p.pending = nil
+	if p.inPlace && !p.quiet && p.done > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProgressFrameDoneAtOrder(t *testing.T) {
+	// 后面的项先完成时暂存，前面的项完成后按序号依次输出
+	var out bytes.Buffer
+	p := &progress{total: 3, out: &out}
+	p.frameDoneAt(2, frameMessage{text: "frame 2"})
+	if out.Len() != 0 {
+		t.Fatalf("printed %q before frame 0 finished", out.String())
+	}
+	p.frameDoneAt(0, frameMessage{text: "frame 0"})
+	p.frameDoneAt(1, frameMessage{text: "frame 1"})
+	p.finish()
+
+	if got, want := out.String(), "frame 0\nframe 1\nframe 2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if p.done != 3 {
+		t.Errorf("done = %d, want 3", p.done)
+	}
+}
+
+func TestProgressFinishFlushesPending(t *testing.T) {
+	// 中途停止时序号 0 不会完成，finish 按序号输出暂存的信息
+	var out bytes.Buffer
+	p := &progress{total: 3, out: &out}
+	p.frameDoneAt(2, frameMessage{text: "frame 2"})
+	p.frameDoneAt(1, frameMessage{text: "frame 1"})
+	p.finish()
+
+	if got, want := out.String(), "frame 1\nframe 2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 将目录中按文件名排序的 PNG/JPG 帧重新编码为 GIF。timingFile 为 -timing-file 写出的 CSV 时，
+// 按行顺序为各帧设置延迟，否则每帧使用 delay（1/100 秒）
+func rebuildGIF(dir, output, timingFile string, delay, loopCount int) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading frame directory: %w", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".png", ".jpg", ".jpeg":
+			if !entry.IsDir() {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no PNG or JPG frames in %s", dir)
+	}
+	slices.Sort(paths)
+
+	var delays []int
+	if timingFile != "" {
+		delays, err = readTimingDelays(timingFile)
+		if err != nil {
+			return fmt.Errorf("reading timing file: %w", err)
+		}
+	}
+
+	g := &gif.GIF{LoopCount: loopCount}
+	for n, path := range paths {
+		img, err := decodeImageFile(path)
+		if err != nil {
+			return err
+		}
+		// 每帧都是完整画面，显示后清除以免透明区域残留上一帧
+		g.Image = append(g.Image, gifconv.Quantize(img))
+		g.Disposal = append(g.Disposal, gif.DisposalBackground)
+		if n < len(delays) {
+			g.Delay = append(g.Delay, delays[n])
+		} else {
+			g.Delay = append(g.Delay, delay)
+		}
+		bounds := img.Bounds()
+		g.Config.Width = max(g.Config.Width, bounds.Max.X)
+		g.Config.Height = max(g.Config.Height, bounds.Max.Y)
+	}
+
+	outFile, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	if err := gif.EncodeAll(outFile, g); err != nil {
+		outFile.Close()
+		os.Remove(output)
+		return fmt.Errorf("encoding: %w", err)
+	}
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully rebuilt %s from %d frames (%s)\n", output, len(g.Image), loopSummary(loopCount))
+	return nil
+}
+
+// 解码单个图像文件（帧或背景图像）
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening image: %w", err)
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// 按行顺序读取时序 CSV 中的 delay 列
+func readTimingDelays(path string) ([]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	column := slices.Index(header, "delay")
+	if column < 0 {
+		return nil, fmt.Errorf("%s has no delay column", path)
+	}
+	var delays []int
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return delays, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		delay, err := strconv.Atoi(record[column])
+		if err != nil {
+			return nil, fmt.Errorf("invalid delay %q: %w", record[column], err)
+		}
+		delays = append(delays, delay)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// 正在写入的输出文件，第二次收到信号立即退出前删除，避免留下写了一半的文件
+var partialFiles = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// 创建输出文件并登记为写入中，写完（或删除）后需调用 doneOutput
+func createOutput(path string) (*os.File, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	partialFiles.Lock()
+	partialFiles.paths[path] = true
+	partialFiles.Unlock()
+	return file, nil
+}
+
+// 取消输出文件的写入中登记
+func doneOutput(path string) {
+	partialFiles.Lock()
+	delete(partialFiles.paths, path)
+	partialFiles.Unlock()
+}
+
+// 收到 SIGINT 或 SIGTERM 时取消 ctx，转换在当前帧完成后停止；
+// 再次收到信号时删除写入中的文件并立即退出。返回的函数报告收到的信号，未收到时为 nil
+func cancelOnSignal(ctx context.Context) (context.Context, func() os.Signal) {
+	ctx, cancel := context.WithCancel(ctx)
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	var mu sync.Mutex
+	var received os.Signal
+	go func() {
+		sig := <-sigs
+		mu.Lock()
+		received = sig
+		mu.Unlock()
+		log.Printf("Received %v, stopping after the current frame (repeat to abort immediately)", sig)
+		cancel()
+
+		sig = <-sigs
+		partialFiles.Lock()
+		for path := range partialFiles.paths {
+			os.Remove(path)
+		}
+		partialFiles.Unlock()
+		log.Printf("Received %v again, aborting", sig)
+		os.Exit(signalExitCode(sig))
+	}()
+
+	return ctx, func() os.Signal {
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+// 按惯例以 128 加信号编号作为退出码
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/draw"
+	"os"
+)
+
+// 精灵图中单帧的位置
+type spriteFrame struct {
+	Frame int `json:"frame"`
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	W     int `json:"w"`
+	H     int `json:"h"`
+}
+
+// 精灵图索引，写入 <base>_sheet.json
+type spriteAtlas struct {
+	Image  string        `json:"image"`
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Frames []spriteFrame `json:"frames"`
+}
+
+// 将各帧按网格排列到一张图像中，columns 不大于 0 时排成一行
+func buildSpriteSheet(frames []image.Image, indices []int, columns int) (*image.RGBA, []spriteFrame) {
+	if columns <= 0 || columns > len(frames) {
+		columns = len(frames)
+	}
+	rows := (len(frames) + columns - 1) / columns
+
+	// 单元格大小取所有帧的最大尺寸
+	var cell image.Point
+	for _, frame := range frames {
+		size := frame.Bounds().Size()
+		cell.X = max(cell.X, size.X)
+		cell.Y = max(cell.Y, size.Y)
+	}
+
+	sheet := image.NewRGBA(image.Rect(0, 0, cell.X*columns, cell.Y*rows))
+	placed := make([]spriteFrame, len(frames))
+	for n, frame := range frames {
+		bounds := frame.Bounds()
+		at := image.Pt((n%columns)*cell.X, (n/columns)*cell.Y)
+		draw.Draw(sheet, bounds.Sub(bounds.Min).Add(at), frame, bounds.Min, draw.Src)
+		placed[n] = spriteFrame{Frame: indices[n], X: at.X, Y: at.Y, W: bounds.Dx(), H: bounds.Dy()}
+	}
+	return sheet, placed
+}
+
+// 将精灵图索引以 JSON 格式写入文件
+func writeAtlasFile(path string, atlas spriteAtlas) error {
+	data, err := json.MarshalIndent(atlas, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"image"
+	"image/gif"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/makotome/gif2png/gifconv"
+)
+
+// 写出帧时序 CSV：帧序号、延迟与累计时间（单位均为 1/100 秒）。
+// fps 大于 0 时写出按固定帧率重新采样后的时序，同一帧可能出现多次
+func writeTimingFile(path string, g *gif.GIF, frames frameRange, fps float64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"frame", "delay", "cumulative"})
+	cumulative := 0
+	if fps > 0 {
+		indices := frames.indices()
+		delays := make([]int, len(indices))
+		for n, i := range indices {
+			delays[n] = frames.delay(g, i)
+		}
+		positions, outDelays := resampleFPS(delays, fps)
+		for k, n := range positions {
+			cumulative += outDelays[k]
+			w.Write([]string{strconv.Itoa(indices[n] + frames.offset), strconv.Itoa(outDelays[k]), strconv.Itoa(cumulative)})
+		}
+	} else {
+		for i := 0; i <= frames.end; i++ {
+			delay := 0
+			if i < len(g.Delay) {
+				delay = g.Delay[i]
+			}
+			cumulative += delay
+			if !frames.contains(i) {
+				continue
+			}
+			w.Write([]string{strconv.Itoa(i + frames.offset), strconv.Itoa(delay), strconv.Itoa(cumulative)})
+		}
+	}
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// 写出输出帧序列的时序汇总 CSV：帧序号、持续时间与累计时间（毫秒）及处置方法，
+// 最后一行为总时长。启用 -dedupe 时额外叠加一遍找出被跳过的重复帧，
+// 其时间并入之前保留的帧，并在 merged 列列出被合并的帧序号
+func writeTimingCSV(ctx context.Context, path string, g *gif.GIF, comp *gifconv.Compositor, frames frameRange, opts *options) error {
+	indices := frames.indices()
+	if opts.dedupe {
+		indices = nil
+		err := compositeFrames(ctx, comp, frames, opts, func(i int, _ *image.RGBA) {
+			indices = append(indices, i)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	delays := frames.mergedDelays(g, indices)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"frame", "delay_ms", "cumulative_ms", "disposal", "merged"})
+	cumulative := 0
+	for n, i := range indices {
+		next := frames.end + 1
+		if n+1 < len(indices) {
+			next = indices[n+1]
+		}
+		var merged []string
+		for j := i + 1; j < next; j++ {
+			if frames.contains(j) {
+				merged = append(merged, strconv.Itoa(j+frames.offset))
+			}
+		}
+		cumulative += delays[n] * 10
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		w.Write([]string{strconv.Itoa(i + frames.offset), strconv.Itoa(delays[n] * 10), strconv.Itoa(cumulative),
+			gifconv.DisposalName(disposal), strings.Join(merged, " ")})
+	}
+	w.Write([]string{"total", "", strconv.Itoa(cumulative), "", ""})
+	w.Flush()
+
+	if err := w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}